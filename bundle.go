@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"golang.org/x/text/language"
+)
+
+// Message 一条被提取的消息
+type Message struct {
+	// ID 消息ID
+	ID string
+	// Other 消息的默认文本（已去除引号和转义）
+	Other string
+	// Description 翻译说明，只在 -source-as-description 时为原文，其余情况为空
+	Description string
+	// Position 字符串在源码中的位置
+	Position token.Position
+}
+
+// referenceOnly 判断消息是否只记录了被引用的ID，如 -tag-ids 替换后结构体标签中的ID，原文已无法从代码中得到
+// 合并消息文件时不添加也不修改这类消息，只保证 -prune 不会删除它们
+func (m Message) referenceOnly() bool {
+	return m.Other == "" && m.Description == ""
+}
+
+// bundle go-i18n TOML 消息文件的内容，键为消息ID，值为消息的各个字段
+type bundle map[string]map[string]string
+
+// bundleDiff 合并消息文件时产生的变更
+type bundleDiff struct {
+	Added   []string
+	Updated []string
+	Removed []string
+}
+
+// empty 判断合并时是否没有变更
+func (d bundleDiff) empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+// loadBundle 读取已有的消息文件，文件不存在时返回空的消息集合
+func loadBundle(path string) (bundle, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return bundle{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, fmt.Errorf("解析消息文件 %s 失败: %v", path, err)
+	}
+
+	b := bundle{}
+	for id, v := range raw {
+		switch v := v.(type) {
+		case string:
+			// 简写形式 id = "文本"
+			b[id] = map[string]string{"other": v}
+		case map[string]interface{}:
+			fields := map[string]string{}
+			for k, fv := range v {
+				s, ok := fv.(string)
+				if !ok {
+					return nil, fmt.Errorf("消息文件 %s 中 %s.%s 不是字符串", path, id, k)
+				}
+				fields[k] = s
+			}
+			b[id] = fields
+		default:
+			return nil, fmt.Errorf("消息文件 %s 中 %s 格式不正确", path, id)
+		}
+	}
+	return b, nil
+}
+
+// mergeBundle 将提取的消息合并到已有消息集合中
+// 新的ID会被添加，other 有变化的会被更新，其余字段（如翻译说明）保持不变；
+// 消息带有说明（即 -source-as-description）时 other 由人工填写，只添加或更新 description
+// prune 为 true 时删除代码中已不再引用的ID
+func mergeBundle(b bundle, msgs []Message, prune bool) bundleDiff {
+	var diff bundleDiff
+	seen := map[string]bool{}
+	referenced := map[string]bool{}
+
+	for _, msg := range msgs {
+		if msg.referenceOnly() {
+			referenced[msg.ID] = true
+			continue
+		}
+		if seen[msg.ID] {
+			continue
+		}
+		seen[msg.ID] = true
+
+		fields, ok := b[msg.ID]
+		if !ok {
+			fields = map[string]string{"other": msg.Other}
+			if msg.Description != "" {
+				fields["description"] = msg.Description
+			}
+			b[msg.ID] = fields
+			diff.Added = append(diff.Added, msg.ID)
+			continue
+		}
+		if msg.Description != "" {
+			if fields["description"] != msg.Description {
+				fields["description"] = msg.Description
+				diff.Updated = append(diff.Updated, msg.ID)
+			}
+			continue
+		}
+		if fields["other"] != msg.Other {
+			fields["other"] = msg.Other
+			diff.Updated = append(diff.Updated, msg.ID)
+		}
+	}
+
+	if prune {
+		for id := range b {
+			if !seen[id] && !referenced[id] {
+				delete(b, id)
+				diff.Removed = append(diff.Removed, id)
+			}
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Updated)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// encodeBundle 将消息集合编码为 TOML，按ID排序以保证输出稳定
+func encodeBundle(b bundle) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBundle 将提取的消息合并进 path 指向的消息文件并输出变更，changed 表示消息有增删改
+// opts.BundleHeader 为 true 时在文件开头写入说明来源的注释
+// opts.BundleFormat 为 po 时改为生成 gettext 模板
+func writeBundle(path string, msgs []Message, opts Options) (changed bool, err error) {
+	if opts.BundleFormat == bundleFormatPO {
+		return writePOTemplate(path, msgs, opts)
+	}
+	b, err := loadBundle(path)
+	if err != nil {
+		return false, err
+	}
+
+	diff := mergeBundle(b, msgs, opts.Prune)
+
+	data, err := encodeBundle(b)
+	if err != nil {
+		return false, err
+	}
+	if opts.BundleHeader {
+		data = append([]byte(bundleHeader(time.Now(), toolVersion(), opts.sourceLanguage())), data...)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+
+	printBundleDiff(os.Stdout, path, diff)
+	return !diff.empty(), nil
+}
+
+// previewBundle 输出将提取的消息合并进 path 指向的消息文件时产生的变更，不写入文件
+func previewBundle(w io.Writer, path string, msgs []Message, opts Options) error {
+	if opts.BundleFormat == bundleFormatPO {
+		printPOSummary(w, path, collectPOEntries(msgs))
+		fmt.Fprintf(w, "预览模式，未写入消息文件 %s\n", path)
+		return nil
+	}
+	b, err := loadBundle(path)
+	if err != nil {
+		return err
+	}
+	printBundleDiff(w, path, mergeBundle(b, msgs, opts.Prune))
+	fmt.Fprintf(w, "预览模式，未写入消息文件 %s\n", path)
+	return nil
+}
+
+// bundleTarget 一个要写入的消息文件，以及其中的消息来自的源文件
+type bundleTarget struct {
+	Path  string
+	Files []*ast.File
+	Msgs  []Message
+}
+
+// bundleTargets 返回要写入的消息文件，结果按路径排序
+// opts.BundlePerPackage 为 true 时按源文件所在的目录（即包）分组，每个目录写入与 path 同名的消息文件，
+// 没有消息的目录同样会得到一个消息文件，以便 -prune 清理；否则所有消息写入 path
+func bundleTargets(path string, files []*ast.File, fset *token.FileSet, msgs []Message, opts Options) []bundleTarget {
+	if !opts.BundlePerPackage {
+		return []bundleTarget{{Path: path, Files: files, Msgs: msgs}}
+	}
+
+	name := filepath.Base(path)
+	index := map[string]int{}
+	var targets []bundleTarget
+	target := func(source string) *bundleTarget {
+		p := filepath.Join(filepath.Dir(source), name)
+		i, ok := index[p]
+		if !ok {
+			i = len(targets)
+			index[p] = i
+			targets = append(targets, bundleTarget{Path: p})
+		}
+		return &targets[i]
+	}
+	for _, file := range files {
+		t := target(fset.File(file.Pos()).Name())
+		t.Files = append(t.Files, file)
+	}
+	for _, msg := range msgs {
+		t := target(msg.Position.Filename)
+		t.Msgs = append(t.Msgs, msg)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Path < targets[j].Path })
+	return targets
+}
+
+// collectWrappedMessages 收集代码中已有的 go-i18n 调用中的消息，需在转换前调用
+// 支持 MustLocalize 和 Localize，从 DefaultMessage 中读取原文，字段名为 opts.MessageField；
+// 写入消息文件时与新提取的消息合并，部分迁移后重新运行时消息文件仍包含全部消息，-prune 也不会删除它们
+func collectWrappedMessages(files []*ast.File, fset *token.FileSet, opts Options) []Message {
+	var msgs []Message
+	for _, file := range files {
+		qualifier := i18nQualifier(file)
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if id, other, description, ok := wrappedMessage(call, qualifier, opts.messageField()); ok {
+				msgs = append(msgs, newMessage(id, other, description, fset.Position(call.Pos()), opts))
+			}
+			return true
+		})
+		msgs = append(msgs, collectTagIDs(file, fset, opts)...)
+	}
+	return msgs
+}
+
+// collectTagIDs 收集结构体标签中 -tag-keys 指定键已被 -tag-ids 替换为消息ID的值，返回只有ID的消息
+// 值中含有中文或不是合法的消息ID时视为原文，由 collectCandidates 处理
+func collectTagIDs(file *ast.File, fset *token.FileSet, opts Options) []Message {
+	if len(opts.TagKeys) == 0 {
+		return nil
+	}
+	wanted := map[string]bool{}
+	for _, key := range opts.TagKeys {
+		wanted[key] = true
+	}
+	var msgs []Message
+	ast.Inspect(file, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+		for _, pair := range parseStructTag(tag) {
+			if wanted[pair.Key] && !hasChinese.MatchString(pair.Value) && validateMessageID(pair.Value) == nil {
+				msgs = append(msgs, Message{ID: pair.Value, Position: fset.Position(field.Tag.Pos())})
+			}
+		}
+		return true
+	})
+	return msgs
+}
+
+// wrappedMessage 解析形如 <Localizer>.MustLocalize(&i18n.LocalizeConfig{MessageID: ..., DefaultMessage: &i18n.Message{<field>: ...}})
+// 的调用，返回消息ID、原文和 Description；Localizer 可以是任意表达式，模板消息同样返回模板文本
+// 包名为 i18nQualifier 返回的 qualifier
+func wrappedMessage(call *ast.CallExpr, qualifier, field string) (id, other, description string, ok bool) {
+	fun, isSel := call.Fun.(*ast.SelectorExpr)
+	if !isSel || (fun.Sel.Name != "MustLocalize" && fun.Sel.Name != "Localize") || len(call.Args) != 1 {
+		return "", "", "", false
+	}
+	config := i18nCompositeLit(call.Args[0], qualifier, "LocalizeConfig")
+	if config == nil {
+		return "", "", "", false
+	}
+
+	for _, elt := range config.Elts {
+		key, value, isKV := keyValue(elt)
+		if !isKV {
+			continue
+		}
+		switch key {
+		case "MessageID":
+			if lit, isLit := value.(*ast.BasicLit); isLit && lit.Kind == token.STRING {
+				id = unquoteLit(lit)
+			}
+		case "DefaultMessage":
+			msg := i18nCompositeLit(value, qualifier, "Message")
+			if msg == nil {
+				continue
+			}
+			for _, melt := range msg.Elts {
+				mkey, mvalue, isKV := keyValue(melt)
+				lit, isLit := mvalue.(*ast.BasicLit)
+				if !isKV || !isLit || lit.Kind != token.STRING {
+					continue
+				}
+				switch mkey {
+				case field:
+					other = unquoteLit(lit)
+					ok = true
+				case "Description":
+					description = unquoteLit(lit)
+				}
+			}
+		}
+	}
+	return id, other, description, ok && id != ""
+}
+
+// defaultSourceLanguage 未指定 -source-lang 时源码中字符串的语言
+const defaultSourceLanguage = "zh-Hans"
+
+// validateSourceLanguage 检查源语言是否为合法的 BCP 47 语言标签，返回规范化后的标签
+func validateSourceLanguage(lang string) (string, error) {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return "", fmt.Errorf("源语言 %q 不是合法的 BCP 47 语言标签: %v", lang, err)
+	}
+	return tag.String(), nil
+}
+
+// bundleFileName 返回源语言的消息文件名，如 active.zh-Hans.toml；gettext 模板使用 .pot 扩展名
+func bundleFileName(lang, format string) string {
+	if format == bundleFormatPO {
+		return "active." + lang + ".pot"
+	}
+	return "active." + lang + ".toml"
+}
+
+// resolveBundlePath 返回消息文件的路径，path 是目录（已存在或以路径分隔符结尾）时在其中使用 bundleFileName
+func resolveBundlePath(path string, opts Options) string {
+	if path == "" {
+		return path
+	}
+	if info, err := os.Stat(path); (err == nil && info.IsDir()) || os.IsPathSeparator(path[len(path)-1]) {
+		return filepath.Join(path, bundleFileName(opts.sourceLanguage(), opts.BundleFormat))
+	}
+	return path
+}
+
+// bundleHeader 返回消息文件开头的注释，说明文件的生成工具、时间和源语言，方便翻译人员了解来源
+func bundleHeader(now time.Time, version, lang string) string {
+	return fmt.Sprintf("# 由 str2go-i18n %s 生成于 %s\n# 源语言: %s\n\n",
+		version, now.Format("2006-01-02"), lang)
+}
+
+// toolVersion 返回构建信息中记录的版本，无法获取时返回 (devel)
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
+
+// printBundleDiff 输出消息文件的变更情况
+func printBundleDiff(w io.Writer, path string, diff bundleDiff) {
+	for _, id := range diff.Added {
+		fmt.Fprintf(w, "新增消息: %s\n", id)
+	}
+	for _, id := range diff.Updated {
+		fmt.Fprintf(w, "更新消息: %s\n", id)
+	}
+	for _, id := range diff.Removed {
+		fmt.Fprintf(w, "删除消息: %s\n", id)
+	}
+	fmt.Fprintf(w, "消息文件 %s: 新增 %d, 更新 %d, 删除 %d\n", path, len(diff.Added), len(diff.Updated), len(diff.Removed))
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeBundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing bundle
+		msgs     []Message
+		prune    bool
+		expected bundle
+		diff     bundleDiff
+	}{
+		{
+			name: "merge new entries keeps existing",
+			existing: bundle{
+				"jqsy": {"other": "旧的消息"},
+			},
+			msgs: []Message{{ID: "nhsj", Other: "你好世界"}},
+			expected: bundle{
+				"jqsy": {"other": "旧的消息"},
+				"nhsj": {"other": "你好世界"},
+			},
+			diff: bundleDiff{Added: []string{"nhsj"}},
+		},
+		{
+			name: "update changed other and keep description",
+			existing: bundle{
+				"nhsj": {"other": "你好", "description": "首页问候语"},
+			},
+			msgs: []Message{{ID: "nhsj", Other: "你好世界"}},
+			expected: bundle{
+				"nhsj": {"other": "你好世界", "description": "首页问候语"},
+			},
+			diff: bundleDiff{Updated: []string{"nhsj"}},
+		},
+		{
+			name: "prune orphaned ids",
+			existing: bundle{
+				"jqsy": {"other": "旧的消息"},
+				"nhsj": {"other": "你好世界"},
+			},
+			msgs:  []Message{{ID: "nhsj", Other: "你好世界"}},
+			prune: true,
+			expected: bundle{
+				"nhsj": {"other": "你好世界"},
+			},
+			diff: bundleDiff{Removed: []string{"jqsy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := mergeBundle(tt.existing, tt.msgs, tt.prune)
+			assert.Equal(t, tt.expected, tt.existing)
+			assert.Equal(t, tt.diff, diff)
+		})
+	}
+}
+
+func TestWriteBundleMergesExistingFile(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "active.zh.toml")
+
+	existing := `# 人工维护的翻译
+jqsy = "旧的消息"
+
+[nhsj]
+description = "首页问候语"
+other = "你好"
+`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatalf("写入消息文件失败: %v", err)
+	}
+
+	changed, err := writeBundle(path, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "zwzfc", Other: "中文字符串"},
+	}, Options{})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	b, err := loadBundle(path)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{
+		"jqsy":  {"other": "旧的消息"},
+		"nhsj":  {"other": "你好世界", "description": "首页问候语"},
+		"zwzfc": {"other": "中文字符串"},
+	}, b)
+}
+
+func TestLoadBundleMissingFile(t *testing.T) {
+	b, err := loadBundle(filepath.Join(t.TempDir(), "missing.toml"))
+	assert.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestBundleHeader(t *testing.T) {
+	header := bundleHeader(time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC), "v1.2.0", defaultSourceLanguage)
+	assert.Equal(t, "# 由 str2go-i18n v1.2.0 生成于 2024-03-05\n# 源语言: zh-Hans\n\n", header)
+}
+
+func TestWriteBundleHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "active.zh.toml")
+	msgs := []Message{{ID: "nhsj", Other: "你好世界"}}
+
+	changed, err := writeBundle(path, msgs, Options{BundleHeader: true})
+	assert.NoError(t, err)
+	assert.True(t, changed)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(string(data), "\n")
+	assert.True(t, strings.HasPrefix(lines[0], "# 由 str2go-i18n "))
+	assert.Equal(t, "# 源语言: zh-Hans", lines[1])
+
+	// 头部注释不影响读取，再次写入时不会重复，消息没有变化
+	changed, err = writeBundle(path, msgs, Options{BundleHeader: true})
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "# 源语言:"))
+
+	b, err := loadBundle(path)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"nhsj": {"other": "你好世界"}}, b)
+}
+
+func TestPreviewBundle(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "active.zh.toml")
+	existing := `jqsy = "旧的消息"
+nhsj = "你好"
+`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatalf("写入消息文件失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err := previewBundle(&buf, path, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "czcg", Other: "操作成功"},
+	}, Options{Prune: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "新增消息: czcg\n更新消息: nhsj\n删除消息: jqsy\n"+
+		"消息文件 "+path+": 新增 1, 更新 1, 删除 1\n"+
+		"预览模式，未写入消息文件 "+path+"\n", buf.String())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, existing, string(data))
+}
+
+func TestDryRunWritesNothing(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var s = "你好世界"
+`)
+	output := filepath.Join(tempDir, "output.go")
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+
+	assert.Equal(t, 0, run([]string{"-dry-run", "-bundle-out", bundlePath, input, output}))
+	for _, path := range []string{output, bundlePath} {
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err), path)
+	}
+}
+
+func TestExtractIncludesWrappedMessages(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(ctx context.Context, n int) {
+	println("你好世界")
+	println(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "welcome", DefaultMessage: &i18n.Message{ID: "welcome", Other: "欢迎回来"}}))
+	s, _ := i18n.GetLocalizer(ctx).Localize(&i18n.LocalizeConfig{MessageID: "page", DefaultMessage: &i18n.Message{ID: "page", Other: "第{{.Arg0}}页"}, TemplateData: map[string]any{"Arg0": n}})
+	println(s)
+}
+`)
+	bundlePath := writeTestFile(t, tempDir, "active.zh.toml", "welcome = \"欢迎\"\nstale = \"旧的消息\"\n")
+
+	// 已替换的消息与新提取的消息一起写入，-prune 只删除代码中不再出现的消息
+	assert.Equal(t, exitChanges, run([]string{"extract", "-prune", "-verify-bundle", "-bundle-out", bundlePath, input}))
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{
+		"nhsj":    {"other": "你好世界"},
+		"welcome": {"other": "欢迎回来"},
+		"page":    {"other": "第{{.Arg0}}页"},
+	}, b)
+
+	// 转换后重新提取，消息文件不变
+	assert.Equal(t, exitChanges, run([]string{"fix", "-prune", "-bundle-out", bundlePath, input}))
+	assert.Equal(t, exitOK, run([]string{"extract", "-prune", "-verify-bundle", "-bundle-out", bundlePath, input}))
+	after, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, b, after)
+}
+
+func TestPruneKeepsAliasedMessages(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+import "example.com/app/i18n"
+
+func example() {
+	i18n.Tr("你好世界")
+}
+`)
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+
+	// 以 goi18n 导入时生成的调用在重新运行时仍被识别，-prune 不会删除它们的消息
+	assert.Equal(t, exitChanges, run([]string{"fix", "-bundle-out", bundlePath, input}))
+	data, err := os.ReadFile(input)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "goi18n.Localizer.MustLocalize(")
+	assert.Equal(t, exitOK, run([]string{"fix", "-prune", "-verify-bundle", "-bundle-out", bundlePath, input}))
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"nhsj": {"other": "你好世界"}}, b)
+}
+
+func TestWrappedMessage(t *testing.T) {
+	_, file := parseSource(t, `package main
+
+var (
+	a = i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "a", DefaultMessage: &i18n.Message{ID: "a", Text: "自定义字段"}})
+	b = i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "b"})
+	c = i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{DefaultMessage: &i18n.Message{Other: "没有ID"}})
+	d = fmt.Sprintf("%s", "x")
+)`)
+	var found []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if id, other, _, ok := wrappedMessage(call, "i18n", "Text"); ok {
+				found = append(found, id+"="+other)
+			}
+		}
+		return true
+	})
+	assert.Equal(t, []string{"a=自定义字段"}, found)
+}
+
+func TestBundlePerPackage(t *testing.T) {
+	tempDir := t.TempDir()
+	user := writeTestFile(t, tempDir, "user/user.go", `package user
+
+var greeting = "你好世界"
+`)
+	userExtra := writeTestFile(t, tempDir, "user/extra.go", `package user
+
+var notice = "操作成功"
+`)
+	order := writeTestFile(t, tempDir, "order/order.go", `package order
+
+var status = "已发货"
+`)
+
+	assert.Equal(t, exitChanges, run([]string{"extract", "-bundle-per-package", "-bundle-out", "active.zh.toml", user, userExtra, order}))
+
+	userBundle, err := loadBundle(filepath.Join(tempDir, "user", "active.zh.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{
+		"nhsj": {"other": "你好世界"},
+		"czcg": {"other": "操作成功"},
+	}, userBundle)
+
+	orderBundle, err := loadBundle(filepath.Join(tempDir, "order", "active.zh.toml"))
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"yfh": {"other": "已发货"}}, orderBundle)
+
+	// 没有指定消息文件名时报错
+	assert.Equal(t, exitUsage, run([]string{"-w", "-bundle-per-package", user}))
+}
+
+func TestValidateSourceLanguage(t *testing.T) {
+	tests := []struct {
+		lang     string
+		expected string
+		wantErr  bool
+	}{
+		{lang: "zh-Hans", expected: "zh-Hans"},
+		{lang: "zh-hant-tw", expected: "zh-Hant-TW"},
+		{lang: "en", expected: "en"},
+		{lang: "中文", wantErr: true},
+		{lang: "zh-", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			lang, err := validateSourceLanguage(tt.lang)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, lang)
+		})
+	}
+}
+
+func TestSourceLang(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	registerPath := filepath.Join(tempDir, "register.go")
+
+	// 指定目录时按源语言生成消息文件名
+	assert.Equal(t, exitChanges, run([]string{"extract", "-source-lang", "zh-hant", "-bundle-header",
+		"-bundle-out", tempDir + string(filepath.Separator), "-register-out", registerPath, input}))
+	data, err := os.ReadFile(filepath.Join(tempDir, "active.zh-Hant.toml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "# 源语言: zh-Hant\n")
+	data, err = os.ReadFile(registerPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `language.MustParse("zh-Hant")`)
+
+	assert.Equal(t, "active.zh-Hans.toml", filepath.Base(resolveBundlePath(tempDir, Options{})))
+	assert.Equal(t, "active.en.pot", filepath.Base(resolveBundlePath(tempDir, Options{SourceLang: "en", BundleFormat: bundleFormatPO})))
+	assert.Equal(t, "messages.toml", filepath.Base(resolveBundlePath(filepath.Join(tempDir, "messages.toml"), Options{})))
+
+	assert.Equal(t, exitUsage, run([]string{"extract", "-source-lang", "中文", "-bundle-out", tempDir, input}))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"text/tabwriter"
+	"unicode"
+)
+
+// charCount 一组字符串的翻译量：不同原文的数量及其中汉字的数量，同一原文只计一次
+type charCount struct {
+	Strings int
+	Chars   int
+	seen    map[string]bool
+}
+
+// add 计入一个原文，已计入过的原文不重复计数
+func (c *charCount) add(other string) {
+	if c.seen[other] {
+		return
+	}
+	if c.seen == nil {
+		c.seen = map[string]bool{}
+	}
+	c.seen[other] = true
+	c.Strings++
+	c.Chars += countHan(other)
+}
+
+// countHan 返回 s 中汉字的数量，标点、字母和数字不计入
+func countHan(s string) int {
+	n := 0
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			n++
+		}
+	}
+	return n
+}
+
+// countCharacters 按当前选项统计待替换字符串的汉字数量并以表格输出到 w，不修改文件，用于估算按字数计费的翻译费用
+// 每个文件一行，最后一行为合计；同一原文在多个文件中出现时合计中只计一次，因此合计可能小于各文件之和
+func countCharacters(w io.Writer, files []string, opts Options) (total charCount, err error) {
+	fset := token.NewFileSet()
+	var paths []string
+	var counts []charCount
+	for _, path := range files {
+		file, skip, err := parseInputFile(fset, path, opts)
+		if err != nil {
+			return charCount{}, err
+		}
+		if skip != "" {
+			fmt.Fprintf(w, "警告: 跳过 %s: %s\n", path, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: path}, "跳过: "+skip))
+			continue
+		}
+		var count charCount
+		for _, c := range collectCandidates(file, fset, opts) {
+			count.add(c.Other)
+			total.add(c.Other)
+		}
+		paths = append(paths, path)
+		counts = append(counts, count)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "文件\t字符串\t汉字")
+	for i, count := range counts {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", paths[i], count.Strings, count.Chars)
+	}
+	fmt.Fprintf(tw, "合计\t%d\t%d\n", total.Strings, total.Chars)
+	return total, tw.Flush()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountHan(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"你好世界", 4},
+		{"你好，世界！", 4},
+		{"第1页 of 10", 2},
+		{"hello", 0},
+		{"繁體字", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.expected, countHan(tt.input))
+		})
+	}
+}
+
+func TestCountCharacters(t *testing.T) {
+	tempDir := t.TempDir()
+	a := writeTestFile(t, tempDir, "a.go", `package main
+
+var greeting = "你好，世界"
+var again = "你好，世界"
+var page = "第1页"
+`)
+	b := writeTestFile(t, tempDir, "b.go", `package main
+
+var greeting = "你好，世界"
+var success = "操作成功"
+`)
+
+	var buf bytes.Buffer
+	total, err := countCharacters(&buf, []string{a, b}, Options{})
+	assert.NoError(t, err)
+	// 同一原文只计一次，标点和数字不计入
+	assert.Equal(t, 3, total.Strings)
+	assert.Equal(t, 4+2+4, total.Chars)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 4) {
+		return
+	}
+	assert.Equal(t, []string{"文件", "字符串", "汉字"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{a, "2", "6"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{b, "2", "8"}, strings.Fields(lines[2]))
+	// 合计小于各文件之和，两个文件中的 "你好，世界" 只翻译一次
+	assert.Equal(t, []string{"合计", "3", "10"}, strings.Fields(lines[3]))
+
+	// 命令行中只统计，不修改文件
+	assert.Equal(t, exitOK, run([]string{"-char-count", a, b}))
+	data, err := os.ReadFile(a)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "i18n")
+	assert.Equal(t, exitUsage, run([]string{"-char-count", "-w", a}))
+}
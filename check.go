@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+)
+
+// checkFiles 检查文件中是否存在需要替换的字符串，不修改文件
+// 每个需要替换的字符串按 文件:行:列: 原文 的格式输出到 w，存在时 found 为 true
+//
+// 配合 pre-commit 使用时，pre-commit 会把暂存的 .go 文件作为参数传入，
+// 发现需要替换的字符串时命令以非零状态退出，从而阻止提交。.pre-commit-config.yaml 示例：
+//
+//	repos:
+//	  - repo: https://github.com/chenwei67/str2go-i18n
+//	    rev: <版本>
+//	    hooks:
+//	      - id: str2go-i18n
+func checkFiles(w io.Writer, files []string, opts Options) (found bool, err error) {
+	for _, path := range files {
+		fset := token.NewFileSet()
+		file, skip, err := parseInputFile(fset, path, opts)
+		if err != nil {
+			return found, err
+		}
+		if skip != "" {
+			fmt.Fprintf(w, "警告: 跳过 %s: %s\n", path, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: path}, "跳过: "+skip))
+			continue
+		}
+
+		for _, c := range pendingCandidates(file, fset, opts) {
+			found = true
+			fmt.Fprintf(w, "%s: %s\n", c.Position, c.Other)
+		}
+	}
+	return found, nil
+}
+
+// pendingCandidates 返回 fix 会修改的字符串
+// 没有 -tag-ids 时标签中的值保持原文，只写入消息文件，不算作需要替换；替换为ID后的值不再是原文，也不会被收集
+func pendingCandidates(file *ast.File, fset *token.FileSet, opts Options) []candidate {
+	var pending []candidate
+	for _, c := range collectCandidates(file, fset, opts) {
+		if c.Tag && !opts.TagIDs {
+			continue
+		}
+		pending = append(pending, c)
+	}
+	return pending
+}
+
+// listFiles 与 gofmt -l 类似，只将包含需要替换的字符串的文件路径逐行输出到 w，不修改文件，存在时 found 为 true
+// 跳过文件的警告输出到标准错误，w 中只有文件路径，便于脚本处理
+func listFiles(w io.Writer, files []string, opts Options) (found bool, err error) {
+	for _, path := range files {
+		fset := token.NewFileSet()
+		file, skip, err := parseInputFile(fset, path, opts)
+		if err != nil {
+			return found, err
+		}
+		if skip != "" {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %s\n", path, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: path}, "跳过: "+skip))
+			continue
+		}
+
+		if len(pendingCandidates(file, fset, opts)) > 0 {
+			found = true
+			fmt.Fprintln(w, path)
+		}
+	}
+	return found, nil
+}
+
+// findMissingMessages 找出文件中待替换的字符串里消息ID不在 bundlePath 指向的消息文件中的字符串，不修改文件
+// 消息ID按 fix 的规则跨文件生成，每个缺少的字符串按 文件:行:列: 消息ID: 原文 的格式输出到 w，存在时 found 为 true
+func findMissingMessages(w io.Writer, files []string, bundlePath string, opts Options) (found bool, err error) {
+	b, err := loadBundle(bundlePath)
+	if err != nil {
+		return false, err
+	}
+
+	fset := token.NewFileSet()
+	var parsed []*ast.File
+	for _, path := range files {
+		file, skip, err := parseInputFile(fset, path, opts)
+		if err != nil {
+			return false, err
+		}
+		if skip != "" {
+			fmt.Fprintf(w, "警告: 跳过 %s: %s\n", path, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: path}, "跳过: "+skip))
+			continue
+		}
+		parsed = append(parsed, file)
+	}
+
+	msgs, err := transformFiles(parsed, fset, opts)
+	if err != nil {
+		return false, err
+	}
+	for _, msg := range msgs {
+		if _, ok := b[msg.ID]; !ok {
+			found = true
+			fmt.Fprintf(w, "%s: %s: %s\n", msg.Position, msg.ID, msg.Other)
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestFile 在临时目录中写入测试文件并返回路径
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+	return path
+}
+
+func TestCheckFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	dirty := writeTestFile(t, tempDir, "dirty.go", `package main
+
+func example() {
+	s := "你好世界"
+}`)
+	clean := writeTestFile(t, tempDir, "clean.go", `package main
+
+// 中文注释
+func example() {
+	s := "Hello"
+}`)
+
+	var buf bytes.Buffer
+	found, err := checkFiles(&buf, []string{clean, dirty}, Options{})
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, dirty+":4:7: 你好世界\n", buf.String())
+
+	buf.Reset()
+	found, err = checkFiles(&buf, []string{clean}, Options{})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, buf.String())
+}
+
+func TestListFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	dirty := writeTestFile(t, tempDir, "dirty.go", `package main
+
+var a = "你好世界"
+var b = "操作成功"
+`)
+	clean := writeTestFile(t, tempDir, "clean.go", `package main
+
+// 中文注释
+var s = "Hello"
+`)
+	other := writeTestFile(t, tempDir, "pkg/other.go", `package pkg
+
+var s = "失败"
+`)
+	generated := writeTestFile(t, tempDir, "gen.go", `// Code generated by tool; DO NOT EDIT.
+
+package main
+
+var s = "生成的"
+`)
+
+	// 每个需要修改的文件只输出一次路径，跳过的文件不出现在列表中
+	var buf bytes.Buffer
+	found, err := listFiles(&buf, []string{dirty, clean, generated, other}, Options{})
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, dirty+"\n"+other+"\n", buf.String())
+
+	buf.Reset()
+	found, err = listFiles(&buf, []string{clean}, Options{})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, buf.String())
+
+	assert.Equal(t, exitChanges, run([]string{"-l", clean, dirty}))
+	assert.Equal(t, exitOK, run([]string{"-l", clean}))
+	assert.Equal(t, exitUsage, run([]string{"-l", "-w", dirty}))
+	assert.Equal(t, exitError, run([]string{"-l", filepath.Join(tempDir, "missing.go")}))
+	// 只列出，不修改文件
+	data, err := os.ReadFile(dirty)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "i18n")
+}
+
+func TestDiffOnlyExitCode(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `package main
+
+func example() {
+	s := "你好世界"
+}`
+	dirty := writeTestFile(t, tempDir, "dirty.go", content)
+	clean := writeTestFile(t, tempDir, "clean.go", `package main
+
+func example() {
+	s := "Hello"
+}`)
+
+	assert.Equal(t, 1, run([]string{"-diff-only", clean, dirty}))
+	assert.Equal(t, 0, run([]string{"-diff-only", clean}))
+	assert.Equal(t, 0, run([]string{"-diff-only"}))
+
+	// 检查模式不修改文件
+	data, err := os.ReadFile(dirty)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestFindMissingMessages(t *testing.T) {
+	tempDir := t.TempDir()
+	a := writeTestFile(t, tempDir, "a.go", `package main
+
+func example() {
+	s := "你好世界"
+	t := "操作成功"
+}`)
+	b := writeTestFile(t, tempDir, "b.go", `package main
+
+var notice = "你好时间"
+`)
+	// 已翻译的消息文件只包含部分ID
+	bundlePath := writeTestFile(t, tempDir, "active.en.toml", "nhsj = \"Hello world\"\n")
+
+	var buf bytes.Buffer
+	found, err := findMissingMessages(&buf, []string{a, b}, bundlePath, Options{})
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, a+":5:7: czcg: 操作成功\n"+
+		b+":3:14: nhsj_"+hashSuffix("你好时间")+": 你好时间\n", buf.String())
+
+	// 不修改文件
+	data, err := os.ReadFile(a)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `s := "你好世界"`)
+
+	assert.Equal(t, 1, run([]string{"-missing-against", bundlePath, a, b}))
+	full := writeTestFile(t, tempDir, "full.toml", "nhsj = \"Hello world\"\nczcg = \"Success\"\n")
+	assert.Equal(t, 0, run([]string{"-missing-against", full, a}))
+}
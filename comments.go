@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// commentString 一条包含中文的单行注释
+type commentString struct {
+	Position token.Position
+	Text     string
+}
+
+// findCommentStrings 找出包含中文的 // 单行注释，这些注释可能是遗漏在注释中的展示文本
+// 只做提示，不修改注释；i18n: 开头的指令注释不在其中
+func findCommentStrings(file *ast.File, fset *token.FileSet) []commentString {
+	var found []commentString
+	for _, commentGroup := range file.Comments {
+		for _, comment := range commentGroup.List {
+			if !strings.HasPrefix(comment.Text, "//") {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			if strings.HasPrefix(text, "i18n:") || !containsChinese(text) {
+				continue
+			}
+			found = append(found, commentString{Position: fset.Position(comment.Pos()), Text: text})
+		}
+	}
+	return found
+}
+
+// printCommentStrings 以 TODO 的形式输出包含中文的注释，供人工确认
+func printCommentStrings(w io.Writer, found []commentString, opts Options) {
+	for _, c := range found {
+		message := fmt.Sprintf("注释中包含中文，请确认是否需要本地化: %s", c.Text)
+		fmt.Fprintf(w, "TODO: %s: %s\n", c.Position, message)
+		opts.logDiagnostic(newDiagnostic(severityWarning, codeCommentString, c.Position, message))
+	}
+}
+
+// commentSnapshot -reattach-comments 在转换前记录的注释归属
+type commentSnapshot struct {
+	// cmap 转换前的注释映射
+	cmap ast.CommentMap
+	// nodes 转换前语法树中的全部节点
+	nodes []ast.Node
+	// imports 转换前是否已有导入声明
+	imports bool
+}
+
+// snapshotComments 在转换前记录注释与节点的对应关系
+func snapshotComments(file *ast.File, fset *token.FileSet) commentSnapshot {
+	snap := commentSnapshot{cmap: ast.NewCommentMap(fset, file, file.Comments)}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n != nil {
+			snap.nodes = append(snap.nodes, n)
+		}
+		return true
+	})
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			snap.imports = true
+		}
+	}
+	return snap
+}
+
+// reattachComments 转换后按转换前的注释归属调整注释的位置，使注释仍紧挨所属的节点：
+// 位于被替换的表达式内部的注释（如 fmt.Sprintf 参数之间的注释）移到替换后的调用之后，
+// 不再被打印到生成的调用中间；新增的导入声明放在第一个声明的前导注释之前，
+// 避免 go/printer 把这些注释打印到导入所在的行
+func reattachComments(file *ast.File, snap commentSnapshot) {
+	present := map[ast.Node]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		present[n] = true
+		return true
+	})
+	var removed []ast.Node
+	for _, n := range snap.nodes {
+		switch n.(type) {
+		case *ast.Comment, *ast.CommentGroup:
+			continue
+		}
+		if !present[n] {
+			removed = append(removed, n)
+		}
+	}
+
+	moved := false
+	for _, group := range file.Comments {
+		// 移到包含注释的最外层被替换节点之后
+		target := token.NoPos
+		for _, n := range removed {
+			if n.Pos() < group.Pos() && group.End() <= n.End() && n.End() > target {
+				target = n.End()
+			}
+		}
+		if target.IsValid() {
+			for _, c := range group.List {
+				c.Slash = target
+			}
+			moved = true
+		}
+	}
+	if moved {
+		sort.SliceStable(file.Comments, func(i, j int) bool { return file.Comments[i].Pos() < file.Comments[j].Pos() })
+	}
+
+	if !snap.imports {
+		placeNewImport(file, snap.cmap)
+	}
+}
+
+// placeNewImport 原文件没有导入声明时，把新增的导入声明移到其后第一个声明的前导注释之前
+// 新位置取注释前一个字符，不能早于包声明及其行尾注释，否则保持不变
+func placeNewImport(file *ast.File, cmap ast.CommentMap) {
+	if len(file.Decls) < 2 {
+		return
+	}
+	imp, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || imp.Tok != token.IMPORT {
+		return
+	}
+	next := file.Decls[1]
+	lead := token.NoPos
+	for _, group := range cmap[next] {
+		if group.End() <= next.Pos() && (!lead.IsValid() || group.Pos() < lead) {
+			lead = group.Pos()
+		}
+	}
+	if !lead.IsValid() || imp.Pos() < lead {
+		return
+	}
+
+	pos := file.Name.End()
+	for _, group := range file.Comments {
+		if group.Pos() < lead && group.End() > pos {
+			pos = group.End()
+		}
+	}
+	imp.TokPos = pos
+	if imp.Lparen.IsValid() {
+		imp.Lparen, imp.Rparen = pos, pos
+	}
+	for _, spec := range imp.Specs {
+		spec := spec.(*ast.ImportSpec)
+		if spec.Name != nil {
+			spec.Name.NamePos = pos
+		}
+		spec.Path.ValuePos = pos
+		spec.EndPos = pos
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCommentStrings(t *testing.T) {
+	fset, file := parseSource(t, `package main
+
+// example 示例函数
+func example() {
+	s := "Hello" // 提示: 欢迎回来
+	/* 块注释中的中文 */
+	t := "你好世界" //i18n:id=greeting
+	// English only
+}`)
+
+	found := findCommentStrings(file, fset)
+	if !assert.Len(t, found, 2) {
+		return
+	}
+	assert.Equal(t, "example 示例函数", found[0].Text)
+	assert.Equal(t, "提示: 欢迎回来", found[1].Text)
+	assert.Equal(t, 5, found[1].Position.Line)
+
+	var buf bytes.Buffer
+	printCommentStrings(&buf, found, Options{})
+	assert.Contains(t, buf.String(), "TODO: 5:15: 注释中包含中文，请确认是否需要本地化: 提示: 欢迎回来\n")
+}
+
+func TestFlagCommentStringsKeepsComments(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+func example() {
+	// 欢迎回来
+	s := "Hello"
+}
+`)
+	output := filepath.Join(tempDir, "output.go")
+
+	assert.Equal(t, 0, run([]string{"-flag-comment-strings", input, output}))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "// 欢迎回来")
+	assert.NotContains(t, string(data), "MustLocalize")
+}
+
+func TestReattachComments(t *testing.T) {
+	input := `package main // 包注释
+
+/* 配置 */
+var (
+	// 问候
+	greeting = "你好" // 行尾
+	page     = fmt.Sprintf("第%d页", // 页码
+		n, // 参数
+	)
+)
+
+func show() {
+	println("中文一", // 参数一
+		"中文二") // 参数二
+}`
+
+	// 默认输出中注释会夹在生成的调用中间，块注释被打印到导入所在的行
+	output, _ := transformString(t, input, Options{ConvertSprintf: true})
+	assert.Contains(t, output, `"Arg0":	// 页码`)
+	assert.Contains(t, output, `"github.com/nicksnyder/go-i18n/v2/i18n"	/* 配置 */`)
+
+	output, msgs := transformString(t, input, Options{ConvertSprintf: true, ReattachComments: true})
+	assert.Len(t, msgs, 4)
+	// 被替换的表达式内部的注释移到生成的调用之后
+	assert.Contains(t, output, `TemplateData: map[string]any{"Arg0": n}})	// 页码
+	// 参数
+)`)
+	// 导入放在声明的注释之前
+	assert.Contains(t, output, "package main // 包注释\nimport \"github.com/nicksnyder/go-i18n/v2/i18n\"\n\n/* 配置 */\nvar (\n\t// 问候\n")
+	// 不在被替换的表达式内部的注释位置不变
+	assert.Regexp(t, `Other: "你好"}}\)\t+// 行尾\n`, output)
+	assert.Contains(t, output, `Other: "中文一"}}),	// 参数一`)
+	assert.Contains(t, output, `Other: "中文二"}}))	// 参数二`)
+
+	// 结果可以重新解析，注释数量不变
+	_, file := parseSource(t, output)
+	assert.Len(t, file.Comments, 8)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// ctxParam 请求级 Localizer 所在的参数名
+const ctxParam = "ctx"
+
+// ctxScopes 带有 ctx 参数的函数的函数体，其中的字符串（包括闭包中的）可以使用 ctx
+type ctxScopes []*ast.BlockStmt
+
+// collectCtxScopes 收集文件中所有带有 ctx 参数的函数和函数字面量
+func collectCtxScopes(file *ast.File) ctxScopes {
+	var scopes ctxScopes
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch fn := n.(type) {
+		case *ast.FuncDecl:
+			if fn.Body != nil && hasCtxParam(fn.Type) {
+				scopes = append(scopes, fn.Body)
+			}
+		case *ast.FuncLit:
+			if hasCtxParam(fn.Type) {
+				scopes = append(scopes, fn.Body)
+			}
+		}
+		return true
+	})
+	return scopes
+}
+
+// hasCtxParam 检查函数是否有名为 ctx 的参数
+func hasCtxParam(fn *ast.FuncType) bool {
+	if fn.Params == nil {
+		return false
+	}
+	for _, field := range fn.Params.List {
+		for _, name := range field.Names {
+			if name.Name == ctxParam {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contains 检查 pos 是否位于某个可以使用 ctx 的函数体中
+func (s ctxScopes) contains(pos token.Pos) bool {
+	for _, body := range s {
+		if body.Pos() <= pos && pos < body.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// newCtxLocalizer 生成从 ctx 中取出 Localizer 的表达式 i18n.GetLocalizer(ctx)
+func newCtxLocalizer() ast.Expr {
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X:   ast.NewIdent("i18n"),
+			Sel: ast.NewIdent("GetLocalizer"),
+		},
+		Args: []ast.Expr{ast.NewIdent(ctxParam)},
+	}
+}
+
+// isCtxLocalizer 检查表达式是否为 i18n.GetLocalizer(x)，包名为 i18nQualifier 返回的 qualifier
+func isCtxLocalizer(expr ast.Expr, qualifier string) bool {
+	call, ok := expr.(*ast.CallExpr)
+	return ok && len(call.Args) == 1 && isI18nType(call.Fun, qualifier, "GetLocalizer")
+}
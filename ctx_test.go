@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizerFromCtx(t *testing.T) {
+	input := `package main
+
+import "context"
+
+var banner = "欢迎使用"
+
+func handle(ctx context.Context) string {
+	done := func() string { return "已完成" }
+	return "处理中" + done()
+}
+
+func plain() string {
+	return "没有上下文"
+}`
+
+	output, msgs := transformString(t, input, Options{LocalizerFromCtx: true})
+	assert.Len(t, msgs, 4)
+	// 带有 ctx 参数的函数及其中的闭包使用 ctx 中的 Localizer
+	assert.Contains(t, output, `return i18n.GetLocalizer(ctx).MustLocalize(&i18n.LocalizeConfig{MessageID: "ywc"`)
+	assert.Contains(t, output, `return i18n.GetLocalizer(ctx).MustLocalize(&i18n.LocalizeConfig{MessageID: "clz"`)
+	// 没有 ctx 时使用默认的 Localizer
+	assert.Contains(t, output, `var banner = i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "mysxw"`)
+
+	// 生成的调用可以被还原
+	fset, file := parseSource(t, output)
+	assert.Equal(t, []string{"clz", "hysy", "mysxw", "ywc"}, revert(file, fset, Options{}))
+}
+
+func TestCustomLocalizerIdempotent(t *testing.T) {
+	input := `package main
+
+import "context"
+
+func handle(ctx context.Context) string {
+	return "处理中"
+}
+
+func plain() string {
+	return "没有上下文"
+}`
+
+	// 再次运行时识别 i18n.GetLocalizer(ctx) 形式的调用，不会重复替换
+	for _, opts := range []Options{{LocalizerFromCtx: true}, {LocalizerFromCtx: true, NoPanic: true}, {LocalizerFromCtx: true, MultilineCalls: true}} {
+		output, msgs := transformString(t, input, opts)
+		assert.Len(t, msgs, 2)
+		again, msgs := transformString(t, output, opts)
+		assert.Empty(t, msgs)
+		assert.Equal(t, output, again)
+	}
+
+	// 手写的调用使用其他 Localizer 时同样识别，判断只依据 LocalizeConfig 和 Message 字面量
+	custom := `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+type server struct{ loc *i18n.Localizer }
+
+type context struct{ L *i18n.Localizer }
+
+func (s *server) greet(ctx context) (string, error) {
+	_ = ctx.L.MustLocalize(&i18n.LocalizeConfig{MessageID: "nh", DefaultMessage: &i18n.Message{ID: "nh", Other: "你好"}})
+	return s.loc.Localize(&i18n.LocalizeConfig{DefaultMessage: &i18n.Message{ID: "zj", Other: "再见"}})
+}
+`
+	output, msgs := transformString(t, custom, Options{LocalizerFromCtx: true})
+	assert.Empty(t, msgs)
+	assert.Equal(t, custom, output)
+}
+
+func TestCollectCtxScopes(t *testing.T) {
+	fset, file := parseSource(t, `package main
+
+func a(ctx context.Context, s string) {}
+
+func b(c context.Context) {}
+
+func c() {
+	f := func(ctx context.Context) {}
+}`)
+
+	scopes := collectCtxScopes(file)
+	assert.Len(t, scopes, 2)
+	assert.Equal(t, 3, fset.Position(scopes[0].Pos()).Line)
+	assert.Equal(t, 8, fset.Position(scopes[1].Pos()).Line)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// go-i18n 的模块路径，生成的代码导入 v2 的 i18nImportPath
+const (
+	i18nModuleV1 = "github.com/nicksnyder/go-i18n"
+	i18nModuleV2 = "github.com/nicksnyder/go-i18n/v2"
+)
+
+// depWarning -check-deps 发现的依赖问题，生成的代码可能无法通过编译
+type depWarning struct {
+	Position token.Position
+	Message  string
+}
+
+// findDependencyWarnings 检查文件所在的项目能否使用生成的 go-i18n v2 调用
+// 文件中导入了 go-i18n v1 时在导入处提示；文件所属的 go.mod 依赖了 v1 或没有依赖 v2 时在 go.mod 处提示，
+// 同一个 go.mod 只检查一次；找不到 go.mod 时不检查依赖
+func findDependencyWarnings(files []*ast.File, fset *token.FileSet) []depWarning {
+	var found []depWarning
+	checked := map[string]bool{}
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || !isI18nV1Import(path) {
+				continue
+			}
+			found = append(found, depWarning{
+				Position: fset.Position(spec.Pos()),
+				Message:  fmt.Sprintf("导入了 go-i18n v1 的 %s，生成的代码使用 %s，同一文件中的两个版本不兼容", path, i18nImportPath),
+			})
+		}
+
+		gomod := findGoMod(filepath.Dir(fset.File(file.Pos()).Name()))
+		if gomod == "" || checked[gomod] {
+			continue
+		}
+		checked[gomod] = true
+		found = append(found, checkGoMod(gomod)...)
+	}
+	return found
+}
+
+// isI18nV1Import 判断导入路径是否属于 go-i18n v1
+func isI18nV1Import(path string) bool {
+	return (path == i18nModuleV1 || strings.HasPrefix(path, i18nModuleV1+"/")) &&
+		path != i18nModuleV2 && !strings.HasPrefix(path, i18nModuleV2+"/")
+}
+
+// findGoMod 从 dir 向上查找 go.mod，找不到时返回空字符串
+func findGoMod(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, "go.mod")
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// checkGoMod 检查 go.mod 中的 go-i18n 依赖：依赖了 v1 或没有依赖 v2 时给出提示
+func checkGoMod(path string) []depWarning {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []depWarning{{Position: token.Position{Filename: path}, Message: fmt.Sprintf("读取 go.mod 失败，无法检查 go-i18n 版本: %v", err)}}
+	}
+	mod, err := modfile.ParseLax(path, data, nil)
+	if err != nil {
+		return []depWarning{{Position: token.Position{Filename: path}, Message: fmt.Sprintf("解析 go.mod 失败，无法检查 go-i18n 版本: %v", err)}}
+	}
+
+	var found []depWarning
+	hasV2 := false
+	for _, req := range mod.Require {
+		switch req.Mod.Path {
+		case i18nModuleV2:
+			hasV2 = true
+		case i18nModuleV1:
+			found = append(found, depWarning{
+				Position: token.Position{Filename: path, Line: req.Syntax.Start.Line},
+				Message:  fmt.Sprintf("依赖了 go-i18n v1 (%s)，生成的代码需要 %s", req.Mod.Version, i18nModuleV2),
+			})
+		}
+	}
+	if !hasV2 {
+		found = append(found, depWarning{
+			Position: token.Position{Filename: path},
+			Message:  fmt.Sprintf("没有依赖 %s，生成的代码无法编译，可以运行 go get %s", i18nModuleV2, i18nModuleV2),
+		})
+	}
+	return found
+}
+
+// printDependencyWarnings 输出依赖问题
+func printDependencyWarnings(w io.Writer, found []depWarning, opts Options) {
+	for _, d := range found {
+		fmt.Fprintf(w, "警告: %s: %s\n", d.Position, d.Message)
+		opts.logDiagnostic(newDiagnostic(severityWarning, codeDependency, d.Position, d.Message))
+	}
+}
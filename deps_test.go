@@ -0,0 +1,113 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// parseTestFiles 解析测试用的文件
+func parseTestFiles(t *testing.T, fset *token.FileSet, paths ...string) []*ast.File {
+	t.Helper()
+	var files []*ast.File
+	for _, path := range paths {
+		file, _, err := parseInputFile(fset, path, Options{})
+		if err != nil {
+			t.Fatalf("解析文件失败: %v", err)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+func TestIsI18nV1Import(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"github.com/nicksnyder/go-i18n/i18n", true},
+		{"github.com/nicksnyder/go-i18n/i18n/language", true},
+		{"github.com/nicksnyder/go-i18n", true},
+		{i18nImportPath, false},
+		{"github.com/nicksnyder/go-i18n/v2", false},
+		{"github.com/nicksnyder/go-i18nx", false},
+		{"fmt", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isI18nV1Import(tt.path))
+		})
+	}
+}
+
+func TestCheckDeps(t *testing.T) {
+	// 项目仍在使用 go-i18n v1
+	v1Dir := t.TempDir()
+	gomod := writeTestFile(t, v1Dir, "go.mod", `module example.com/app
+
+go 1.21
+
+require github.com/nicksnyder/go-i18n v1.10.1
+`)
+	path := writeTestFile(t, v1Dir, "main.go", `package main
+
+import (
+	"fmt"
+
+	"github.com/nicksnyder/go-i18n/i18n"
+)
+
+var T i18n.TranslateFunc
+
+func example() {
+	fmt.Println("你好世界")
+}
+`)
+	other := writeTestFile(t, filepath.Join(v1Dir, "sub"), "other.go", `package sub
+
+var s = "成功"
+`)
+
+	fset := token.NewFileSet()
+	found := findDependencyWarnings(parseTestFiles(t, fset, path, other), fset)
+	if assert.Len(t, found, 3) {
+		assert.Equal(t, path, found[0].Position.Filename)
+		assert.Equal(t, 6, found[0].Position.Line)
+		assert.Contains(t, found[0].Message, "go-i18n v1")
+		// 同一个 go.mod 只检查一次
+		assert.Equal(t, gomod, found[1].Position.Filename)
+		assert.Equal(t, 5, found[1].Position.Line)
+		assert.Contains(t, found[1].Message, "v1.10.1")
+		assert.Equal(t, gomod, found[2].Position.Filename)
+		assert.Contains(t, found[2].Message, "go get "+i18nModuleV2)
+	}
+
+	// 只给出警告，照常转换
+	logPath := filepath.Join(t.TempDir(), "log.jsonl")
+	assert.Equal(t, exitChanges, run([]string{"-check-deps", "-log-json", logPath, "-w", path}))
+	data, err := os.ReadFile(logPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), `"code":"dependency"`))
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "MustLocalize(")
+
+	// 已依赖 v2 时没有警告
+	v2Dir := t.TempDir()
+	writeTestFile(t, v2Dir, "go.mod", `module example.com/app
+
+go 1.21
+
+require github.com/nicksnyder/go-i18n/v2 v2.4.0
+`)
+	path = writeTestFile(t, v2Dir, "main.go", `package main
+
+var s = "你好世界"
+`)
+	assert.Empty(t, findDependencyWarnings(parseTestFiles(t, fset, path), fset))
+}
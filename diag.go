@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"go/token"
+)
+
+// 诊断信息的严重程度
+const (
+	severityWarning = "warning"
+	severityError   = "error"
+)
+
+// 诊断信息的类别
+const (
+	codeInvalidIDAnnotation   = "invalid-id-annotation"
+	codeInvalidArgsAnnotation = "invalid-args-annotation"
+	codePlural                = "plural"
+	codeSkippedFile           = "skipped-file"
+	codeIDCollision           = "id-collision"
+	codeCommentString         = "comment-string"
+	codeNoCtx                 = "no-ctx"
+	codeEmbeddedNumber        = "embedded-number"
+	codeNoPanic               = "no-panic"
+	codeInvalidID             = "invalid-id"
+	codeDependency            = "dependency"
+)
+
+// Diagnostic 一条诊断信息，-log-json 时每条占一行，便于其他工具读取
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// newDiagnostic 根据源码位置创建诊断信息
+func newDiagnostic(severity, code string, pos token.Position, message string) Diagnostic {
+	return Diagnostic{Severity: severity, Code: code, Message: message, File: pos.Filename, Line: pos.Line}
+}
+
+// logDiagnostic 将诊断信息以 JSON Lines 格式写入 o.DiagnosticLog，未设置时忽略
+// 人类可读的输出由调用方负责，这里只做补充
+func (o Options) logDiagnostic(d Diagnostic) {
+	if o.recorded != nil {
+		*o.recorded = append(*o.recorded, d)
+	}
+	if o.DiagnosticLog == nil {
+		return
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	o.DiagnosticLog.Write(append(data, '\n'))
+}
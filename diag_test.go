@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogDiagnostic(t *testing.T) {
+	var buf bytes.Buffer
+	opts := Options{DiagnosticLog: &buf}
+	opts.logDiagnostic(newDiagnostic(severityWarning, codePlural, token.Position{Filename: "a.go", Line: 3, Column: 7}, "包含数量"))
+
+	assert.Equal(t, `{"severity":"warning","code":"plural","message":"包含数量","file":"a.go","line":3}`+"\n", buf.String())
+
+	// 未设置日志时不输出
+	Options{}.logDiagnostic(newDiagnostic(severityWarning, codePlural, token.Position{}, "包含数量"))
+}
+
+// readDiagnostics 读取 JSON Lines 格式的诊断日志
+func readDiagnostics(t *testing.T, path string) []Diagnostic {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("打开日志文件失败: %v", err)
+	}
+	defer f.Close()
+
+	var diags []Diagnostic
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var d Diagnostic
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			t.Fatalf("日志行不是合法的 JSON: %q: %v", scanner.Text(), err)
+		}
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+func TestLogJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+func example() {
+	s := "登录标题" //i18n:id=1登录
+	n := "共 3 条"
+}`)
+	output := filepath.Join(tempDir, "output.go")
+	logPath := filepath.Join(tempDir, "diagnostics.jsonl")
+
+	assert.Equal(t, exitChanges, run([]string{"-log-json", logPath, "-warn-plural", input, output}))
+
+	diags := readDiagnostics(t, logPath)
+	if assert.Len(t, diags, 2) {
+		assert.Equal(t, Diagnostic{
+			Severity: severityWarning,
+			Code:     codePlural,
+			Message:  `"共 3 条" 包含数量，可能需要复数形式`,
+			File:     input,
+			Line:     5,
+		}, diags[0])
+		assert.Equal(t, codeInvalidIDAnnotation, diags[1].Code)
+		assert.Equal(t, 4, diags[1].Line)
+	}
+}
+
+func TestLogJSONCollision(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+func example() {
+	a := "你好世界"
+	b := "你好时间"
+}`)
+	logPath := filepath.Join(tempDir, "diagnostics.jsonl")
+
+	assert.Equal(t, exitError, run([]string{"-log-json", logPath, "-unique-suffix", "none", input, filepath.Join(tempDir, "output.go")}))
+
+	diags := readDiagnostics(t, logPath)
+	if assert.Len(t, diags, 1) {
+		assert.Equal(t, severityError, diags[0].Severity)
+		assert.Equal(t, codeIDCollision, diags[0].Code)
+		assert.Equal(t, input, diags[0].File)
+		assert.Equal(t, 5, diags[0].Line)
+	}
+}
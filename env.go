@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix 提供参数默认值的环境变量前缀
+const envPrefix = "STR2GO_"
+
+// envName 返回参数对应的环境变量名，如 pinyin-style 为 STR2GO_PINYIN_STYLE
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnvDefaults 用环境变量设置参数的默认值，需在解析命令行参数前调用，命令行中的参数优先
+// 布尔参数的值与命令行相同，如 STR2GO_REUSE_IN_FUNC=true
+func applyEnvDefaults(fs *flag.FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok || err != nil {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("环境变量 %s 的值 %q 无效: %v", envName(f.Name), value, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "STR2GO_PINYIN_STYLE", envName("pinyin-style"))
+	assert.Equal(t, "STR2GO_BUNDLE_OUT", envName("bundle-out"))
+	assert.Equal(t, "STR2GO_W", envName("w"))
+}
+
+func TestEnvDefaults(t *testing.T) {
+	content := `package main
+
+var greeting = "你好世界"
+`
+	tempDir := t.TempDir()
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+	t.Setenv("STR2GO_PINYIN_STYLE", pinyinStyleFull)
+	t.Setenv("STR2GO_BUNDLE_OUT", bundlePath)
+	t.Setenv("STR2GO_LOCALIZER_FROM_CTX", "true")
+
+	// 环境变量设置默认值
+	path := writeTestFile(t, tempDir, "a.go", content)
+	assert.Equal(t, exitChanges, run([]string{"fix", path}))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "ni_hao_shi_jie"`)
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"ni_hao_shi_jie": {"other": "你好世界"}}, b)
+
+	// 命令行中的参数优先
+	path = writeTestFile(t, tempDir, "b.go", content)
+	assert.Equal(t, exitChanges, run([]string{"fix", "-pinyin-style", pinyinStyleFirstLetter, path}))
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "nhsj"`)
+
+	// 值无效时与命令行参数一样报错
+	t.Setenv("STR2GO_LOCALIZER_FROM_CTX", "maybe")
+	assert.Equal(t, exitUsage, run([]string{"fix", path}))
+	t.Setenv("STR2GO_LOCALIZER_FROM_CTX", "false")
+	t.Setenv("STR2GO_PINYIN_STYLE", "tone")
+	assert.Equal(t, exitUsage, run([]string{"fix", path}))
+}
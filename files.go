@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+)
+
+// parseInputFile 解析待处理的文件
+// 生成的文件（带有 // Code generated ... DO NOT EDIT. 标记）和超过 opts.MaxSize 的文件不做处理，
+// opts.Safe 时有语法错误的文件也不做处理，此时 file 为空，skip 为跳过的原因
+func parseInputFile(fset *token.FileSet, path string, opts Options) (file *ast.File, skip string, err error) {
+	if opts.MaxSize > 0 {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, "", err
+		}
+		if info.Size() > opts.MaxSize {
+			return nil, fmt.Sprintf("文件大小 %d 字节超过限制 %d 字节", info.Size(), opts.MaxSize), nil
+		}
+	}
+
+	mode := parser.ParseComments
+	if opts.Safe {
+		mode |= parser.AllErrors
+	}
+	file, err = parser.ParseFile(fset, path, nil, mode)
+	var syntax scanner.ErrorList
+	if opts.Safe && errors.As(err, &syntax) {
+		return nil, syntaxErrorSkip(syntax), nil
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if ast.IsGenerated(file) {
+		return nil, "生成的文件", nil
+	}
+	return file, "", nil
+}
+
+// syntaxErrorSkip 返回有语法错误的文件被跳过的原因，列出全部错误，同一行只保留第一个，位置中省略文件名
+func syntaxErrorSkip(errs scanner.ErrorList) string {
+	errs.RemoveMultiples()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d 处语法错误", len(errs))
+	for _, e := range errs {
+		fmt.Fprintf(&b, "; %d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+	}
+	return b.String()
+}
+
+// readFileList 读取换行分隔的文件列表，忽略空行和首尾空白
+func readFileList(r io.Reader) ([]string, error) {
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if path := strings.TrimSpace(scanner.Text()); path != "" {
+			files = append(files, path)
+		}
+	}
+	return files, scanner.Err()
+}
+
+// loadFileList 读取 -files-from 指定的文件列表，path 为 - 时从标准输入读取
+func loadFileList(path string) ([]string, error) {
+	if path == "-" {
+		return readFileList(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFileList(f)
+}
+
+// parseArgs 解析命令行参数并返回其中的文件，参数可以出现在文件之后，如 fix input.go -o output.go
+// -- 之后的参数都作为文件
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return files, nil
+		}
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(files, rest...), nil
+		}
+		files = append(files, rest[0])
+		args = rest[1:]
+	}
+}
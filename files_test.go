@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInputFileSkipsGenerated(t *testing.T) {
+	tempDir := t.TempDir()
+	path := writeTestFile(t, tempDir, "bindata.go", `// Code generated by go-bindata. DO NOT EDIT.
+
+package main
+
+var s = "你好世界"
+`)
+
+	file, skip, err := parseInputFile(token.NewFileSet(), path, Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, file)
+	assert.Equal(t, "生成的文件", skip)
+
+	// 检查模式同样跳过生成的文件
+	var buf bytes.Buffer
+	found, err := checkFiles(&buf, []string{path}, Options{})
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Contains(t, buf.String(), "跳过")
+
+	// 转换模式不生成输出文件
+	output := filepath.Join(tempDir, "output.go")
+	assert.Equal(t, 0, run([]string{path, output}))
+	_, err = os.Stat(output)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestParseInputFileMaxSize(t *testing.T) {
+	content := `package main
+
+var s = "你好世界"
+`
+	path := writeTestFile(t, t.TempDir(), "large.go", content)
+
+	file, skip, err := parseInputFile(token.NewFileSet(), path, Options{MaxSize: 10})
+	assert.NoError(t, err)
+	assert.Nil(t, file)
+	assert.Contains(t, skip, "超过限制")
+
+	file, skip, err = parseInputFile(token.NewFileSet(), path, Options{MaxSize: int64(len(content))})
+	assert.NoError(t, err)
+	assert.NotNil(t, file)
+	assert.Empty(t, skip)
+}
+
+func TestSafeMode(t *testing.T) {
+	tempDir := t.TempDir()
+	broken := writeTestFile(t, tempDir, "broken.go", `package main
+
+func example() {
+	s := "你好世界"
+	if s == {
+	}
+	return )
+}
+`)
+	good := writeTestFile(t, tempDir, "good.go", `package main
+
+var s = "操作成功"
+`)
+
+	// 收集全部语法错误作为跳过的原因，同一行的错误只保留一个
+	file, skip, err := parseInputFile(token.NewFileSet(), broken, Options{Safe: true})
+	assert.NoError(t, err)
+	assert.Nil(t, file)
+	assert.Regexp(t, `^3 处语法错误; 5:10: .+; 7:2: `, skip)
+	_, _, err = parseInputFile(token.NewFileSet(), broken, Options{})
+	assert.Error(t, err)
+	_, _, err = parseInputFile(token.NewFileSet(), filepath.Join(tempDir, "missing.go"), Options{Safe: true})
+	assert.Error(t, err)
+
+	// 默认任何文件解析失败时不写入任何结果
+	assert.Equal(t, exitError, run([]string{"-w", broken, good}))
+	data, err := os.ReadFile(good)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "i18n")
+
+	// 安全模式跳过有语法错误的文件，其他文件照常处理
+	before, err := os.ReadFile(broken)
+	assert.NoError(t, err)
+	assert.Equal(t, exitChanges, run([]string{"-w", "-safe", broken, good}))
+	data, err = os.ReadFile(good)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "i18n.Localizer.MustLocalize(")
+	data, err = os.ReadFile(broken)
+	assert.NoError(t, err)
+	assert.Equal(t, string(before), string(data))
+}
+
+func TestReadFileList(t *testing.T) {
+	files, err := readFileList(strings.NewReader("a.go\n\n  pkg/b.go \r\nc.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "pkg/b.go", "c.go"}, files)
+}
+
+func TestFilesFrom(t *testing.T) {
+	tempDir := t.TempDir()
+	dirty := writeTestFile(t, tempDir, "dirty.go", `package main
+
+var s = "你好世界"
+`)
+	clean := writeTestFile(t, tempDir, "clean.go", `package main
+
+var s = "Hello"
+`)
+	cleanList := writeTestFile(t, tempDir, "clean.txt", clean+"\n")
+	dirtyList := writeTestFile(t, tempDir, "dirty.txt", clean+"\n"+dirty+"\n")
+
+	assert.Equal(t, 0, run([]string{"-diff-only", "-files-from", cleanList}))
+	assert.Equal(t, 1, run([]string{"-diff-only", "-files-from", dirtyList}))
+	// 命令行参数与列表中的文件一起检查
+	assert.Equal(t, 1, run([]string{"-diff-only", "-files-from", cleanList, dirty}))
+
+	assert.Equal(t, exitError, run([]string{"-diff-only", "-files-from", filepath.Join(tempDir, "missing.txt")}))
+	assert.Equal(t, exitUsage, run([]string{"-files-from", cleanList, clean, filepath.Join(tempDir, "out.go")}))
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args   []string
+		files  []string
+		write  bool
+		bundle string
+	}{
+		{[]string{"-w", "a.go", "b.go"}, []string{"a.go", "b.go"}, true, ""},
+		{[]string{"a.go", "-bundle-out", "zh.toml", "b.go", "-w"}, []string{"a.go", "b.go"}, true, "zh.toml"},
+		{[]string{"-bundle-out", "zh.toml", "--", "a.go", "-w"}, []string{"a.go", "-w"}, false, "zh.toml"},
+		{nil, nil, false, ""},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		write := fs.Bool("w", false, "")
+		bundleOut := fs.String("bundle-out", "", "")
+		files, err := parseArgs(fs, tt.args)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.files, files, "%v", tt.args)
+		assert.Equal(t, tt.write, *write, "%v", tt.args)
+		assert.Equal(t, tt.bundle, *bundleOut, "%v", tt.args)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	_, err := parseArgs(fs, []string{"a.go", "-unknown"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// flagDefault 参数定义函数中默认值参数的位置
+type flagDefault struct {
+	// Index 默认值参数的下标
+	Index int
+	// Arity 函数的参数个数，用于区分同名的其他方法
+	Arity int
+}
+
+// builtinFlagDefaults 标准库 flag 包中字符串参数的定义函数，pflag 中的同名函数参数相同
+// 同名的 *flag.FlagSet 方法（如 fs.String）按参数个数匹配，接收者由 isFlagReceiver 检查
+var builtinFlagDefaults = map[string]flagDefault{
+	"String":    {Index: 1, Arity: 3},
+	"StringVar": {Index: 2, Arity: 4},
+}
+
+// flagImportPaths 提供 builtinFlagDefaults 中参数定义函数的包
+var flagImportPaths = []string{"flag", "github.com/spf13/pflag"}
+
+// flagPackageNames 返回文件中导入 flagImportPaths 中的包使用的包名，不包括 _ 和 . 导入
+func flagPackageNames(file *ast.File) map[string]bool {
+	names := map[string]bool{}
+	for _, spec := range file.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, p := range flagImportPaths {
+			if path != p {
+				continue
+			}
+			name := p[strings.LastIndex(p, "/")+1:]
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if name != "_" && name != "." {
+				names[name] = true
+			}
+		}
+	}
+	return names
+}
+
+// isFlagDefault 检查当前节点是否是参数定义函数的默认值参数，如 flag.String("name", "默认", "说明") 中的 "默认"
+// custom 为 -flag-default-args 指定的 函数名→默认值参数下标，按调用处的写法匹配；
+// pkgs 为 flagPackageNames 返回的包名
+func isFlagDefault(cursor *astutil.Cursor, custom map[string]int, pkgs map[string]bool) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	index := argIndex(call, cursor.Node())
+	if index < 0 {
+		return false
+	}
+
+	if i, ok := custom[calleeName(call)]; ok {
+		return i == index
+	}
+
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	def, ok := builtinFlagDefaults[sel.Sel.Name]
+	return ok && def.Index == index && def.Arity == len(call.Args) && isFlagReceiver(sel.X, pkgs)
+}
+
+// isFlagReceiver 检查参数定义函数的接收者是否为 flag 包、flag.CommandLine，或在文件中声明为 *flag.FlagSet 的变量，
+// 如 fs *flag.FlagSet 参数、var fs *flag.FlagSet 以及 fs := flag.NewFlagSet(...)；其他同名方法不是参数定义函数
+func isFlagReceiver(expr ast.Expr, pkgs map[string]bool) bool {
+	switch x := expr.(type) {
+	case *ast.ParenExpr:
+		return isFlagReceiver(x.X, pkgs)
+	case *ast.Ident:
+		if x.Obj == nil {
+			return pkgs[x.Name]
+		}
+		return isFlagSetVar(x, pkgs)
+	case *ast.SelectorExpr:
+		return isFlagPackageSelector(x, pkgs, "CommandLine")
+	}
+	return false
+}
+
+// isFlagSetVar 根据变量在文件中的声明判断其类型是否为 *flag.FlagSet
+func isFlagSetVar(ident *ast.Ident, pkgs map[string]bool) bool {
+	if ident.Obj.Kind != ast.Var {
+		return false
+	}
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return isFlagSetType(decl.Type, pkgs)
+	case *ast.ValueSpec:
+		if decl.Type != nil {
+			return isFlagSetType(decl.Type, pkgs)
+		}
+		for i, name := range decl.Names {
+			if name.Name == ident.Name && i < len(decl.Values) {
+				return isNewFlagSet(decl.Values[i], pkgs)
+			}
+		}
+	case *ast.AssignStmt:
+		if len(decl.Lhs) != len(decl.Rhs) {
+			return false
+		}
+		for i, lhs := range decl.Lhs {
+			if name, ok := lhs.(*ast.Ident); ok && name.Name == ident.Name {
+				return isNewFlagSet(decl.Rhs[i], pkgs)
+			}
+		}
+	}
+	return false
+}
+
+// isFlagSetType 检查类型表达式是否为 *flag.FlagSet
+func isFlagSetType(expr ast.Expr, pkgs map[string]bool) bool {
+	star, ok := expr.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := star.X.(*ast.SelectorExpr)
+	return ok && isFlagPackageSelector(sel, pkgs, "FlagSet")
+}
+
+// isNewFlagSet 检查表达式是否得到 *flag.FlagSet，如 flag.NewFlagSet(...)、&flag.FlagSet{} 和 flag.CommandLine
+func isNewFlagSet(expr ast.Expr, pkgs map[string]bool) bool {
+	switch x := expr.(type) {
+	case *ast.CallExpr:
+		sel, ok := x.Fun.(*ast.SelectorExpr)
+		return ok && isFlagPackageSelector(sel, pkgs, "NewFlagSet")
+	case *ast.UnaryExpr:
+		lit, ok := x.X.(*ast.CompositeLit)
+		if !ok || x.Op != token.AND {
+			return false
+		}
+		sel, ok := lit.Type.(*ast.SelectorExpr)
+		return ok && isFlagPackageSelector(sel, pkgs, "FlagSet")
+	case *ast.SelectorExpr:
+		return isFlagPackageSelector(x, pkgs, "CommandLine")
+	}
+	return false
+}
+
+// isFlagPackageSelector 检查选择器是否为 flag 包中名为 name 的成员，如 flag.FlagSet
+func isFlagPackageSelector(sel *ast.SelectorExpr, pkgs map[string]bool, name string) bool {
+	pkg, ok := sel.X.(*ast.Ident)
+	return ok && pkg.Obj == nil && pkgs[pkg.Name] && sel.Sel.Name == name
+}
+
+// argIndex 返回节点在调用参数中的下标，不是参数时返回 -1
+func argIndex(call *ast.CallExpr, node ast.Node) int {
+	for i, arg := range call.Args {
+		if arg == node {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseArgPositions 解析 函数名:下标 形式的逗号分隔列表，如 cfg.Define:1,env.Get:1
+func parseArgPositions(s string) (map[string]int, error) {
+	positions := map[string]int{}
+	for _, item := range splitList(s) {
+		i := strings.LastIndex(item, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("参数位置 %q 格式不正确，应为 函数名:下标", item)
+		}
+		index, err := strconv.Atoi(item[i+1:])
+		if err != nil || index < 0 {
+			return nil, fmt.Errorf("参数位置 %q 中的下标不正确", item)
+		}
+		positions[item[:i]] = index
+	}
+	return positions, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagDefaults(t *testing.T) {
+	input := `package main
+
+import "flag"
+
+var (
+	name = flag.String("name", "默认名称", "用户名称")
+	fs   = flag.NewFlagSet("cmd", flag.ExitOnError)
+)
+
+func init() {
+	flag.StringVar(&mode, "mode", "普通模式", "运行模式")
+	fs.String("lang", "中文", "界面语言")
+	cfg.Define("title", "默认标题", "页面标题")
+}`
+
+	_, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "yhmc", Other: "用户名称"},
+		{ID: "yxms", Other: "运行模式"},
+		{ID: "jmyy", Other: "界面语言"},
+		{ID: "mrbt", Other: "默认标题"},
+		{ID: "ymbt", Other: "页面标题"},
+	}, msgs)
+
+	// 自定义函数的默认值位置
+	_, msgs = transformString(t, input, Options{FlagDefaultArgs: map[string]int{"cfg.Define": 1}})
+	assert.NotContains(t, msgs, Message{ID: "mrbt", Other: "默认标题"})
+	assert.Len(t, msgs, 4)
+
+	// 开启后默认值同样被替换
+	_, msgs = transformString(t, input, Options{WrapFlagDefaults: true})
+	assert.Len(t, msgs, 8)
+}
+
+// 只有 flag、pflag 包及 *FlagSet 的同名方法是参数定义函数，其他类型的 String 方法照常替换
+func TestFlagDefaultReceivers(t *testing.T) {
+	input := `package main
+
+import (
+	"flag"
+
+	pf "github.com/spf13/pflag"
+)
+
+type store struct{}
+
+func (store) String(key, value, note string) {}
+
+var global *flag.FlagSet
+
+func setup(set *flag.FlagSet, s store) {
+	var cmd = flag.NewFlagSet("cmd", flag.ExitOnError)
+	set.String("a", "默认一", "")
+	global.String("b", "默认二", "")
+	cmd.String("c", "默认三", "")
+	flag.CommandLine.String("d", "默认四", "")
+	pf.String("e", "默认五", "")
+	s.String("key", "存储的值", "")
+	store{}.String("key", "其他值", "")
+}`
+
+	_, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "ccdz", Other: "存储的值"},
+		{ID: "qtz", Other: "其他值"},
+	}, msgs)
+}
+
+func TestParseArgPositions(t *testing.T) {
+	positions, err := parseArgPositions("cfg.Define:1, env.Get:2")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"cfg.Define": 1, "env.Get": 2}, positions)
+
+	_, err = parseArgPositions("cfg.Define")
+	assert.Error(t, err)
+	_, err = parseArgPositions("cfg.Define:x")
+	assert.Error(t, err)
+}
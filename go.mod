@@ -3,13 +3,17 @@ module str2go-i18n
 go 1.23.7
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/mozillazg/go-pinyin v0.20.0
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/stretchr/testify v1.10.0
+	golang.org/x/mod v0.24.0
+	golang.org/x/text v0.23.0
 	golang.org/x/tools v0.31.0
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// candidate 第一遍收集到的一个待替换字符串
+type candidate struct {
+	// Other 字符串解码后的内容
+	Other string
+	// AnnotatedID 注释中指定的合法ID，未指定时为空
+	AnnotatedID string
+	// AnnotationErr 注释中指定的ID不合法时的错误
+	AnnotationErr error
+	// ArgsErr 注释中指定的模板字段名不合法时的错误
+	ArgsErr error
+	// HTML 字符串是 template.HTML 等类型转换的参数，开启 -html-typed-aware 时生成ID前去除标签
+	HTML bool
+	// Position 字符串在源码中的位置
+	Position token.Position
+	// Tag 字符串是结构体标签中 -tag-keys 指定键的值
+	Tag bool
+	// Existing 文件中已有的 go-i18n 调用，AnnotatedID 为去掉目录前缀的消息ID，只用于预留已使用的ID
+	Existing bool
+}
+
+// collectCandidates 收集文件中所有待替换的字符串，不修改语法树
+// 注释中指定的ID在这里校验，不合法时记录在 AnnotationErr 中并忽略该ID
+func collectCandidates(file *ast.File, fset *token.FileSet, opts Options) []candidate {
+	var cands []candidate
+	annotations := collectIDAnnotations(file, fset)
+	annotate := func(c *candidate) {
+		if id, ok := annotations.take(c.Position.Line); ok {
+			if err := validateMessageID(id); err != nil {
+				c.AnnotationErr = err
+			} else {
+				c.AnnotatedID = id
+			}
+		}
+	}
+
+	walkSprintfs(file, fset, opts, func(cursor *astutil.Cursor, msg sprintfMessage) {
+		c := candidate{Other: msg.Template, ArgsErr: msg.ArgsErr, Position: fset.Position(msg.Format.Pos())}
+		annotate(&c)
+		cands = append(cands, c)
+	})
+
+	walkStrings(file, opts, func(cursor *astutil.Cursor, lit *ast.BasicLit) {
+		c := candidate{Other: unquoteLit(lit), Position: fset.Position(lit.Pos())}
+		c.HTML = opts.HTMLTypedAware && isHTMLTypedConversion(cursor)
+		annotate(&c)
+		cands = append(cands, c)
+	})
+
+	rewriteTagMessages(file, opts.TagKeys, func(lit *ast.BasicLit, value string) string {
+		cands = append(cands, candidate{Other: value, Position: fset.Position(lit.Pos()), Tag: true})
+		return value
+	})
+
+	// fmt.Sprintf 和普通字符串分两遍遍历，按源码位置排序后与替换时选取的顺序一致
+	sort.SliceStable(cands, func(i, j int) bool { return cands[i].Position.Offset < cands[j].Position.Offset })
+	return cands
+}
+
+// firstCandidates 返回按源码位置排序的前 limit 个待替换字符串的偏移量，用于 -limit；结构体标签不计入
+func firstCandidates(cands []candidate, limit int) map[int]bool {
+	first := map[int]bool{}
+	for _, c := range cands {
+		if len(first) >= limit {
+			break
+		}
+		if !c.Tag {
+			first[c.Position.Offset] = true
+		}
+	}
+	return first
+}
+
+// existingCandidates 将文件中已有的 go-i18n 调用转换为指定了ID的候选，需在转换前调用
+// 计算ID时这些ID视为已被占用：与其冲突的新原文追加后缀，与已有调用相同的原文沿用其ID
+func existingCandidates(files []*ast.File, fset *token.FileSet, opts Options) []candidate {
+	var cands []candidate
+	for _, msg := range collectWrappedMessages(files, fset, opts) {
+		if msg.referenceOnly() {
+			continue
+		}
+		other := msg.Other
+		if msg.Description != "" {
+			other = msg.Description
+		}
+		id := msg.ID
+		if prefix := opts.idPrefix(msg.Position.Filename); prefix != "" {
+			id = strings.TrimPrefix(id, prefix+idPrefixSeparator)
+		}
+		if validateMessageID(id) != nil {
+			continue
+		}
+		cands = append(cands, candidate{Other: other, AnnotatedID: id, Position: msg.Position, Existing: true})
+	}
+	return cands
+}
+
+// collectMessages 收集文件中所有待替换的字符串并计算最终ID，不修改语法树
+func collectMessages(file *ast.File, fset *token.FileSet, opts Options) ([]Message, error) {
+	cands := collectCandidates(file, fset, opts)
+	ids, err := planMessageIDs(append(cands, existingCandidates([]*ast.File{file}, fset, opts)...), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ids = applyIDPrefix(ids, annotatedTexts(cands), fset.File(file.Pos()).Name(), opts)
+	msgs := make([]Message, 0, len(cands))
+	for _, c := range cands {
+		id := ids[c.Other]
+		if c.AnnotatedID != "" {
+			id = c.AnnotatedID
+		}
+		msgs = append(msgs, Message{ID: id, Other: c.Other, Position: c.Position})
+	}
+	return msgs, nil
+}
+
+// printAnnotationWarnings 输出不合法的ID注释和字段名注释
+func printAnnotationWarnings(cands []candidate, opts Options) {
+	for _, c := range cands {
+		if c.AnnotationErr != nil {
+			message := fmt.Sprintf("%v，使用自动生成的ID", c.AnnotationErr)
+			fmt.Printf("警告: %s: %s\n", c.Position, message)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeInvalidIDAnnotation, c.Position, message))
+		}
+		if c.ArgsErr != nil {
+			message := fmt.Sprintf("%v，使用默认的字段名", c.ArgsErr)
+			fmt.Printf("警告: %s: %s\n", c.Position, message)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeInvalidArgsAnnotation, c.Position, message))
+		}
+	}
+}
+
+// CollisionSource 冲突ID对应的一个原文
+type CollisionSource struct {
+	Text     string
+	Position token.Position
+}
+
+// CollisionError 不同原文得到了相同的消息ID
+type CollisionError struct {
+	ID     string
+	First  CollisionSource
+	Second CollisionSource
+}
+
+// Error 实现 error，给出两处原文的位置和可选的解决办法
+func (e *CollisionError) Error() string {
+	return fmt.Sprintf("消息ID %q 冲突: %q (%s) 与 %q (%s) 生成了相同的ID；"+
+		"可以增大 -id-length、使用 -unique-suffix=hash 追加哈希后缀，或通过 //i18n:id= 注释指定ID",
+		e.ID, e.First.Text, e.First.Position, e.Second.Text, e.Second.Position)
+}
+
+// InvalidIDError 自动生成的消息ID不符合 go-i18n 的要求，只在 -strict-ids 时返回
+type InvalidIDError struct {
+	ID       string
+	Text     string
+	Position token.Position
+}
+
+// Error 实现 error，给出原文的位置和生成的ID
+func (e *InvalidIDError) Error() string {
+	return fmt.Sprintf("%s: 原文 %q 生成的消息ID %q 不合法；可以通过 //i18n:id= 注释指定ID，或去掉 -strict-ids 改用 msg_ 加哈希的ID",
+		e.Position, e.Text, e.ID)
+}
+
+// checkGeneratedID 检查自动生成的ID是否符合 go-i18n 的要求，防止拼音转换的特殊情况写出无法加载的消息文件
+// 不合法时 opts.StrictIDs 为 true 返回 *InvalidIDError，否则改用 msg_ 加原文的哈希
+func checkGeneratedID(c candidate, id string, opts Options) (string, error) {
+	if validateMessageID(id) == nil {
+		return id, nil
+	}
+	if opts.StrictIDs {
+		return "", &InvalidIDError{ID: id, Text: c.Other, Position: c.Position}
+	}
+	return "msg_" + hashSuffix(c.Other), nil
+}
+
+// 冲突ID的处理方式
+const (
+	// uniqueSuffixHash 追加由原文计算的哈希后缀
+	uniqueSuffixHash = "hash"
+	// uniqueSuffixNone 不追加后缀，出现冲突时报错
+	uniqueSuffixNone = "none"
+	// uniqueSuffixCounter 按出现顺序追加 _1、_2 等序号
+	uniqueSuffixCounter = "counter"
+)
+
+// validateUniqueSuffix 检查冲突ID的处理方式是否受支持
+func validateUniqueSuffix(mode string) error {
+	switch mode {
+	case "", uniqueSuffixHash, uniqueSuffixNone, uniqueSuffixCounter:
+		return nil
+	}
+	return fmt.Errorf("不支持的冲突处理方式: %s", mode)
+}
+
+// baseMessageIDs 计算每个原文处理冲突前的ID，annotated 记录ID由注释指定的原文
+// 同一原文以第一个注释指定的ID为准，自动生成的ID不合法时按 checkGeneratedID 处理
+func baseMessageIDs(cands []candidate, opts Options) (ids map[string]string, annotated map[string]bool, err error) {
+	ids = map[string]string{}
+	annotated = map[string]bool{}
+	for _, c := range cands {
+		if c.AnnotatedID != "" {
+			if !annotated[c.Other] {
+				ids[c.Other] = c.AnnotatedID
+				annotated[c.Other] = true
+			}
+			continue
+		}
+		if _, ok := ids[c.Other]; !ok {
+			idOpts := opts
+			if c.HTML {
+				idOpts.HTMLAware = true
+			}
+			id, err := checkGeneratedID(c, generateMessageID(strconv.Quote(c.Other), idOpts), opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			ids[c.Other] = id
+		}
+	}
+	return ids, annotated, nil
+}
+
+// planMessageIDs 根据收集到的全部字符串计算每个原文的最终ID
+// 注释指定了ID的原文使用该ID，其余原文使用自动生成的ID；
+// 多个不同原文得到同一ID时，指定了该ID的原文、首尾没有空白的原文或按字典序最小的原文保留该ID，
+// 其余原文追加由原文计算的哈希后缀。结果只取决于原文集合，与出现顺序无关
+// 开启繁简转换时，转换后相同的原文视为同一文本，共用一个ID
+// opts.UniqueSuffix 为 none 时不追加后缀，遇到冲突返回 *CollisionError；
+// 为 counter 时以出现顺序代替字典序，其余原文按顺序追加 _1、_2 等序号，结果取决于出现顺序
+func planMessageIDs(cands []candidate, opts Options) (map[string]string, error) {
+	ids, annotated, err := baseMessageIDs(cands, opts)
+	if err != nil {
+		return nil, err
+	}
+	positions := map[string]token.Position{}
+	order := map[string]int{}
+	for i, c := range cands {
+		if _, ok := positions[c.Other]; !ok {
+			positions[c.Other] = c.Position
+			order[c.Other] = i
+		}
+	}
+
+	// 按ID分组检查冲突，组内再按文本归并
+	groups := map[string]map[string][]string{}
+	for other, id := range ids {
+		key := textKey(other, opts)
+		if groups[id] == nil {
+			groups[id] = map[string][]string{}
+		}
+		groups[id][key] = append(groups[id][key], other)
+	}
+
+	// 按ID排序处理，保证报告的冲突是确定的
+	groupIDs := make([]string, 0, len(groups))
+	for id := range groups {
+		groupIDs = append(groupIDs, id)
+	}
+	sort.Strings(groupIDs)
+
+	for _, id := range groupIDs {
+		byKey := groups[id]
+		if len(byKey) < 2 {
+			continue
+		}
+		isAnnotated := func(key string) bool {
+			for _, other := range byKey[key] {
+				if annotated[other] {
+					return true
+				}
+			}
+			return false
+		}
+		spaced := func(key string) bool {
+			return key != strings.TrimSpace(key)
+		}
+		// firstSeen 返回文本最早出现的顺序
+		firstSeen := func(key string) int {
+			seen := len(cands)
+			for _, other := range byKey[key] {
+				if order[other] < seen {
+					seen = order[other]
+				}
+			}
+			return seen
+		}
+		keys := make([]string, 0, len(byKey))
+		for key := range byKey {
+			sort.Strings(byKey[key])
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			// 注释指定了ID的文本排在最前
+			if isAnnotated(keys[i]) != isAnnotated(keys[j]) {
+				return isAnnotated(keys[i])
+			}
+			// 首尾没有空白的文本优先保留ID，如 "你好" 与 "你好 "
+			if spaced(keys[i]) != spaced(keys[j]) {
+				return !spaced(keys[i])
+			}
+			if opts.UniqueSuffix == uniqueSuffixCounter {
+				return firstSeen(keys[i]) < firstSeen(keys[j])
+			}
+			return keys[i] < keys[j]
+		})
+
+		if opts.UniqueSuffix == uniqueSuffixNone {
+			first, second := byKey[keys[0]][0], byKey[keys[1]][0]
+			return nil, &CollisionError{
+				ID:     id,
+				First:  CollisionSource{Text: first, Position: positions[first]},
+				Second: CollisionSource{Text: second, Position: positions[second]},
+			}
+		}
+		n := 0
+		for _, key := range keys[1:] {
+			suffix := hashSuffix(key)
+			if opts.UniqueSuffix == uniqueSuffixCounter {
+				suffix, n = nextCounterSuffix(id, n, ids)
+			}
+			for _, other := range byKey[key] {
+				if !annotated[other] {
+					ids[other] = id + "_" + suffix
+				}
+			}
+		}
+	}
+	return ids, nil
+}
+
+// nextCounterSuffix 返回 id 在 n 之后第一个未被其他原文使用的序号后缀，以及该序号
+func nextCounterSuffix(id string, n int, ids map[string]string) (string, int) {
+	used := map[string]bool{}
+	for _, planned := range ids {
+		used[planned] = true
+	}
+	for {
+		n++
+		suffix := strconv.Itoa(n)
+		if !used[id+"_"+suffix] {
+			return suffix, n
+		}
+	}
+}
+
+// textKey 返回判断两个原文是否为同一文本时使用的键
+func textKey(other string, opts Options) string {
+	if opts.Traditional2Simplified {
+		return toSimplified(other)
+	}
+	return other
+}
+
+// hashSuffix 根据原文计算用于区分冲突ID的哈希后缀
+func hashSuffix(message string) string {
+	hash := md5.Sum([]byte(message))
+	return fmt.Sprintf("%x", hash)[:8] // 取前8位
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanMessageIDsOrderIndependent(t *testing.T) {
+	forward := `package main
+
+func example() {
+	a := "你好世界"
+	b := "你好时间"
+	c := "你好世界"
+}`
+	backward := `package main
+
+func example() {
+	b := "你好时间"
+	a := "你好世界"
+	c := "你好时间"
+}`
+
+	_, forwardMsgs := transformString(t, forward, Options{})
+	_, backwardMsgs := transformString(t, backward, Options{})
+
+	idsOf := func(msgs []Message) map[string]string {
+		ids := map[string]string{}
+		for _, msg := range msgs {
+			ids[msg.Other] = msg.ID
+		}
+		return ids
+	}
+
+	expected := map[string]string{
+		"你好世界": "nhsj",
+		"你好时间": "nhsj_" + hashSuffix("你好时间"),
+	}
+	assert.Equal(t, expected, idsOf(forwardMsgs))
+	assert.Equal(t, expected, idsOf(backwardMsgs))
+}
+
+func TestPlanMessageIDs(t *testing.T) {
+	tests := []struct {
+		name     string
+		cands    []candidate
+		expected map[string]string
+	}{
+		{
+			name:  "no collision",
+			cands: []candidate{{Other: "你好世界"}, {Other: "操作成功"}, {Other: "你好世界"}},
+			expected: map[string]string{
+				"你好世界": "nhsj",
+				"操作成功": "czcg",
+			},
+		},
+		{
+			name:  "collision gets hash suffix",
+			cands: []candidate{{Other: "你好时间"}, {Other: "你好世界"}},
+			expected: map[string]string{
+				"你好世界": "nhsj",
+				"你好时间": "nhsj_" + hashSuffix("你好时间"),
+			},
+		},
+		{
+			name: "annotated string keeps its id",
+			cands: []candidate{
+				{Other: "你好世界"},
+				{Other: "随便写的", AnnotatedID: "nhsj"},
+			},
+			expected: map[string]string{
+				"随便写的": "nhsj",
+				"你好世界": "nhsj_" + hashSuffix("你好世界"),
+			},
+		},
+		{
+			name: "unannotated occurrence reuses annotation",
+			cands: []candidate{
+				{Other: "登录标题"},
+				{Other: "登录标题", AnnotatedID: "login_title"},
+			},
+			expected: map[string]string{
+				"登录标题": "login_title",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := planMessageIDs(tt.cands, Options{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
+}
+
+func TestTransformWithIDs(t *testing.T) {
+	input := `package main
+
+func example() {
+	a := "你好世界"
+	b := "操作成功"
+}`
+
+	fset, file := parseSource(t, input)
+	msgs := stripPositions(transformWithIDs(file, fset, Options{}, map[string]string{"你好世界": "greeting"}))
+	assert.Equal(t, []Message{
+		{ID: "greeting", Other: "你好世界"},
+		{ID: "czcg", Other: "操作成功"},
+	}, msgs)
+}
+
+func TestCollisionError(t *testing.T) {
+	src := `package main
+
+func example() {
+	a := "你好时间"
+	b := "你好世界"
+}`
+
+	fset, file := parseSource(t, src)
+	_, err := transform(file, fset, Options{UniqueSuffix: uniqueSuffixNone})
+
+	var collision *CollisionError
+	if !assert.ErrorAs(t, err, &collision) {
+		return
+	}
+	assert.Equal(t, "nhsj", collision.ID)
+	assert.Equal(t, "你好世界", collision.First.Text)
+	assert.Equal(t, 5, collision.First.Position.Line)
+	assert.Equal(t, "你好时间", collision.Second.Text)
+	assert.Equal(t, 4, collision.Second.Position.Line)
+
+	msg := err.Error()
+	assert.Contains(t, msg, `"你好世界" (5:7)`)
+	assert.Contains(t, msg, `"你好时间" (4:7)`)
+	assert.Contains(t, msg, "-id-length")
+	assert.Contains(t, msg, "-unique-suffix=hash")
+	assert.Contains(t, msg, "//i18n:id=")
+}
+
+func TestIDLength(t *testing.T) {
+	src := `package main
+
+func example() {
+	a := "你好世界一"
+	b := "你好世界二"
+}`
+
+	fset, file := parseSource(t, src)
+	_, err := transform(file, fset, Options{UniqueSuffix: uniqueSuffixNone, IDLength: 4})
+	assert.Error(t, err)
+
+	_, msgs := transformString(t, src, Options{UniqueSuffix: uniqueSuffixNone})
+	assert.Equal(t, []Message{
+		{ID: "nhsjy", Other: "你好世界一"},
+		{ID: "nhsje", Other: "你好世界二"},
+	}, msgs)
+}
+
+func TestUniqueSuffixCounter(t *testing.T) {
+	src := `package main
+
+func example() {
+	a := "你好时间"
+	b := "你好世界"
+	c := "你好时间"
+	d := "尼好事件"
+	e := "登录" //i18n:id=nhsj_1
+}`
+
+	// 最先出现的原文保留ID，其余按出现顺序编号，已被使用的ID会被跳过
+	_, msgs := transformString(t, src, Options{UniqueSuffix: uniqueSuffixCounter})
+	assert.Equal(t, []Message{
+		{ID: "nhsj", Other: "你好时间"},
+		{ID: "nhsj_2", Other: "你好世界"},
+		{ID: "nhsj", Other: "你好时间"},
+		{ID: "nhsj_3", Other: "尼好事件"},
+		{ID: "nhsj_1", Other: "登录"},
+	}, msgs)
+
+	// 结果是确定的
+	_, again := transformString(t, src, Options{UniqueSuffix: uniqueSuffixCounter})
+	assert.Equal(t, msgs, again)
+
+	cands := []candidate{{Other: "你好世界"}, {Other: "操作成功"}, {Other: "你好时间"}}
+	ids, err := planMessageIDs(cands, Options{UniqueSuffix: uniqueSuffixCounter})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"你好世界": "nhsj",
+		"操作成功": "czcg",
+		"你好时间": "nhsj_1",
+	}, ids)
+}
+
+func TestWhitespaceVariants(t *testing.T) {
+	src := `package main
+
+func example() {
+	a := "你好"
+	b := "你好 "
+	c := "\t你好"
+}`
+
+	// 默认去掉空白后生成相同的ID，再追加哈希后缀区分
+	_, msgs := transformString(t, src, Options{})
+	assert.Equal(t, []Message{
+		{ID: "nh", Other: "你好"},
+		{ID: "nh_" + hashSuffix("你好 "), Other: "你好 "},
+		{ID: "nh_" + hashSuffix("\t你好"), Other: "\t你好"},
+	}, msgs)
+
+	// 开启后带空白的字符串直接使用 _ws 后缀，多个带空白的变体之间仍按 -unique-suffix 区分
+	_, msgs = transformString(t, src, Options{WhitespaceSensitiveIDs: true, UniqueSuffix: uniqueSuffixCounter})
+	assert.Equal(t, []Message{
+		{ID: "nh", Other: "你好"},
+		{ID: "nh_ws", Other: "你好 "},
+		{ID: "nh_ws_1", Other: "\t你好"},
+	}, msgs)
+
+	assert.True(t, hasSurroundingSpace(`你好\n`))
+	assert.False(t, hasSurroundingSpace(`你 好`))
+}
+
+func TestCheckGeneratedID(t *testing.T) {
+	c := candidate{Other: "你好", Position: token.Position{Filename: "a.go", Line: 3, Column: 7}}
+
+	id, err := checkGeneratedID(c, "nh", Options{StrictIDs: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "nh", id)
+
+	// 不合法的ID默认改用 msg_ 加哈希
+	for _, bad := range []string{"nǐ", "1a", "n h", "", "_nh"} {
+		id, err = checkGeneratedID(c, bad, Options{})
+		assert.NoError(t, err)
+		assert.Equal(t, "msg_"+hashSuffix("你好"), id)
+	}
+
+	// 严格模式给出原文、位置和生成的ID
+	_, err = checkGeneratedID(c, "nǐ", Options{StrictIDs: true})
+	var invalid *InvalidIDError
+	if assert.True(t, errors.As(err, &invalid)) {
+		assert.Equal(t, InvalidIDError{ID: "nǐ", Text: "你好", Position: c.Position}, *invalid)
+	}
+	assert.Contains(t, err.Error(), `a.go:3:7: 原文 "你好" 生成的消息ID "nǐ" 不合法`)
+}
+
+func TestGeneratedIDCharset(t *testing.T) {
+	// 各种容易产生非 ASCII 字符的输入，生成的ID都应符合 go-i18n 的要求
+	inputs := []string{
+		"嗯", "呣", "欸", "㖿", "𠀀", "〇", "々", "乄",
+		"绿色", "女儿", "略", "ü", "ǘ中",
+		"１２３中文", "Ⅻ", "①②", "ß中", "é", "naïve",
+		"😀中文", "中\u200b文", "中\u0301", "ａｂｃ",
+		"日本語のテキスト", "한국어", "עברית", "العربية",
+		"  ", "\t\n", "123", "-", ".中", "%d个",
+	}
+	styles := []Options{
+		{},
+		{PinyinStyle: pinyinStyleFull, IDSeparator: "_"},
+		{PinyinStyle: pinyinStyleFull, IDCase: idCasePascal},
+		{IDCase: idCaseSnake, WhitespaceSensitiveIDs: true},
+		{HTMLAware: true, Traditional2Simplified: true},
+	}
+
+	var cands []candidate
+	for _, input := range inputs {
+		cands = append(cands, candidate{Other: input})
+	}
+	for _, opts := range styles {
+		opts.StrictIDs = true
+		ids, _, err := baseMessageIDs(cands, opts)
+		if !assert.NoError(t, err, "%+v", opts) {
+			continue
+		}
+		for other, id := range ids {
+			assert.NoError(t, validateMessageID(id), "%q: %q", other, id)
+		}
+	}
+}
@@ -1,39 +1,254 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/mozillazg/go-pinyin"
 	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/imports"
 	"unicode"
+	"unicode/utf8"
 )
 
 var hasChinese = regexp.MustCompile(`\p{Han}`)
 
+// idAnnotation 匹配指定消息ID的注释，如 //i18n:id=login_title
+var idAnnotation = regexp.MustCompile(`^//\s*i18n:id=(\S*)`)
+
+// htmlTag 匹配 HTML 标签，如 <b>、</b>、<a href="...">
+var htmlTag = regexp.MustCompile(`</?[a-zA-Z][^<>]*>`)
+
+// validMessageID 合法的 go-i18n 消息ID
+var validMessageID = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]*$`)
+
+// Options 控制转换行为，零值即为默认行为
+type Options struct {
+	// IDScheme 自动生成消息ID的方式，可选 pinyin、hash，为空时使用 pinyin；
+	// hash 生成 msg_ 加完整原文哈希的ID，不使用拼音，IDCase、PinyinStyle、IDSeparator 和 IDLength 不起作用
+	IDScheme string
+	// IDCase 消息ID的大小写风格，可选 snake、camel、pascal，为空时直接拼接
+	IDCase string
+	// PinyinStyle 中文转为ID时使用的拼音风格，可选 first-letter、full，为空时使用 first-letter
+	PinyinStyle string
+	// IDSeparator IDCase 为空时连接各个拼音或单词的分隔符
+	// 命令行未指定 -id-separator 时 first-letter 风格为空、full 风格为 _
+	IDSeparator string
+	// BundleOut 消息文件输出路径，为空时不输出
+	BundleOut string
+	// SourceLang 源码中字符串的语言，BCP 47 语言标签，为空时使用 zh-Hans
+	SourceLang string
+	// BundleFormat 消息文件格式，toml（默认）为 go-i18n 消息文件，po 为 gettext 模板
+	BundleFormat string
+	// BundlePerPackage 按源文件所在的目录分别写入消息文件，文件名取 BundleOut 的文件名
+	BundlePerPackage bool
+	// VerifyBundle 写入消息文件后检查代码引用的消息ID与消息文件是否一一对应
+	VerifyBundle bool
+	// DiagnosticLog 以 JSON Lines 格式记录警告和错误，为空时不记录
+	DiagnosticLog io.Writer
+	// recorded 不为 nil 时同时记录所有诊断信息，用于生成迁移报告
+	recorded *[]Diagnostic
+	// MessageField 生成的 Message 中保存原文的字段名，为空时使用 go-i18n 的 Other
+	MessageField string
+	// StrictIDs 自动生成的消息ID不合法时报错，默认改用 msg_ 加哈希的ID
+	StrictIDs bool
+	// ReuseInFunc 同一函数中多次出现的字符串只调用一次 go-i18n，结果赋给局部变量供之后的出现引用
+	ReuseInFunc bool
+	// ReattachComments 按转换前的注释映射调整注释位置，避免注释被打印到生成的调用中间或新增的导入之后
+	ReattachComments bool
+	// MultilineCalls 生成的调用中 LocalizeConfig 的每个字段各占一行
+	MultilineCalls bool
+	// NoPanic 在 s := "中文" 等可以多接收一个返回值的位置生成返回 (string, error) 的 Localize，并用 _ 忽略 error；
+	// 其他位置给出警告并仍使用 MustLocalize
+	NoPanic bool
+	// LocalizerFromCtx 在带有 ctx 参数的函数中使用 i18n.GetLocalizer(ctx) 代替 i18n.Localizer
+	LocalizerFromCtx bool
+	// Force 允许覆盖已存在且内容不同的输出文件
+	Force bool
+	// GoImports 写入前按 goimports 的规则整理导入分组
+	GoImports bool
+	// DryRun 只输出消息文件将产生的变更，不写入任何文件
+	DryRun bool
+	// BundleHeader 在消息文件开头写入生成工具、时间和源语言的注释
+	BundleHeader bool
+	// RegisterOut 注册消息的 Go 源文件输出路径，为空时不输出
+	RegisterOut string
+	// Prune 合并消息文件时删除代码中不再引用的ID
+	Prune bool
+	// WrapIndexKeys 同样替换作为索引键或 map 字面量键使用的字符串，如 m["中文"]、gin.H{"中文": v}
+	WrapIndexKeys bool
+	// Detector 判断字符串是否需要替换，为空时使用 ChineseDetector
+	Detector StringDetector
+	// Revert 将生成的 go-i18n 调用还原为原始字符串
+	Revert bool
+	// HTMLAware 生成ID前去除看起来是 HTML 片段的字符串中的标签
+	HTMLAware bool
+	// WhitespaceSensitiveIDs 首尾带空白的字符串生成的ID追加 _ws 后缀，与去掉空白后的字符串直接区分；
+	// 默认两者生成相同的ID，再按 UniqueSuffix 区分，消息文件中不会有同一ID对应两个原文
+	WhitespaceSensitiveIDs bool
+	// HTMLTypedAware 生成ID前去除 template.HTML 等类型转换中字符串的标签，不影响其他字符串
+	HTMLTypedAware bool
+	// Sinks 不为空时只替换直接作为这些函数参数的字符串，函数名按调用处的写法匹配，如 fmt.Fprintf、c.JSON
+	Sinks []string
+	// AnnotatePosition 在生成的 Message 的 Description 中记录字符串的源码位置
+	AnnotatePosition bool
+	// SourceAsDescription 将原文写入生成的 Message 的 Description 作为翻译说明，Other 为 OtherPlaceholder，
+	// 由人工在消息文件中填写；Other 为空且消息文件中没有该消息时 MustLocalize 会 panic
+	SourceAsDescription bool
+	// OtherPlaceholder SourceAsDescription 为 true 时写入 Other 的占位文本，默认为空
+	OtherPlaceholder string
+	// Traditional2Simplified 生成ID前将繁体字转换为简体字，繁简写法不同的同一文本共用一个ID
+	Traditional2Simplified bool
+	// TagKeys 提取结构体标签中这些键的中文值，如 label、placeholder
+	TagKeys []string
+	// TagIDs 将 TagKeys 指定键的中文值替换为对应的消息ID，运行时再按ID查找翻译
+	TagIDs bool
+	// WarnPlural 提示包含数量、可能需要复数形式的字符串
+	WarnPlural bool
+	// EmbeddedNumbers 包含与中文相邻的数字的字符串的处理方式，warn 提示后照常替换，skip 提示并跳过；为空时不处理
+	EmbeddedNumbers string
+	// FlagCommentStrings 以 TODO 的形式提示包含中文的单行注释，不修改注释
+	FlagCommentStrings bool
+	// UseSpaces 输出时使用空格缩进
+	UseSpaces bool
+	// TabWidth 缩进宽度，为 0 时使用 8
+	TabWidth int
+	// WrapFlagDefaults 同样替换参数定义函数中的默认值，如 flag.String("name", "默认", "说明") 中的 "默认"
+	WrapFlagDefaults bool
+	// FlagDefaultArgs 自定义参数定义函数的默认值参数下标，函数名按调用处的写法匹配
+	FlagDefaultArgs map[string]int
+	// WrapArgs 这些函数只替换指定下标的参数，其余参数中的字符串保持不变，函数名按调用处的写法匹配
+	WrapArgs map[string]int
+	// MaxSize 跳过超过该字节数的文件，为 0 时不限制
+	MaxSize int64
+	// CheckDeps 转换前检查文件导入和所属 go.mod 中的 go-i18n 版本，使用 v1 或没有依赖 v2 时给出警告
+	CheckDeps bool
+	// IDPrefixes 目录→前缀，目录下（包括子目录）的文件自动生成的消息ID加上 前缀_，多个目录都包含文件时使用最深的目录；
+	// 注释指定的ID不加前缀
+	IDPrefixes map[string]string
+	// Safe 收集全部语法错误，有语法错误的文件给出警告并跳过，继续处理其他文件；为 false 时解析失败即停止
+	Safe bool
+	// IDLength 自动生成ID时最多使用的字符数，为 0 时使用 5
+	IDLength int
+	// WrapParents 只替换父节点为这些类型的字符串，使用 go/ast 的类型名，如 AssignStmt、CallExpr；为空时不限制
+	WrapParents []string
+	// SkipByteConversions 不替换 []byte("中文") 转换中的字符串；默认替换，结果仍是合法的 []byte 转换
+	SkipByteConversions bool
+	// ConvertSprintf 将格式字符串包含中文的 fmt.Sprintf 调用整体转换为带 TemplateData 的模板消息
+	ConvertSprintf bool
+	// Limit 每个文件最多替换的字符串数量，按出现顺序选取，为 0 时不限制
+	Limit int
+	// UniqueSuffix 不同原文得到相同ID时的处理方式，hash（默认）追加哈希后缀，counter 按出现顺序追加序号，none 报错
+	UniqueSuffix string
+}
+
+// StringDetector 判断一个字符串字面量是否需要替换为 go-i18n 调用
+// 结构体标签、已替换的字符串、注释等情况在调用 ShouldWrap 之前已被排除
+// 对于由 + 连接合并而成的字面量，cursor 指向原始的连接表达式；对于 fmt.Sprintf 的格式字符串，cursor 指向整个调用
+type StringDetector interface {
+	ShouldWrap(lit *ast.BasicLit, cursor *astutil.Cursor) bool
+}
+
+// ChineseDetector 默认的检测规则，替换包含中文的字符串
+type ChineseDetector struct{}
+
+// ShouldWrap 实现 StringDetector
+func (ChineseDetector) ShouldWrap(lit *ast.BasicLit, cursor *astutil.Cursor) bool {
+	return hasChinese.MatchString(lit.Value)
+}
+
+// idLength 返回自动生成ID时最多使用的字符数
+func (o Options) idLength() int {
+	if o.IDLength > 0 {
+		return o.IDLength
+	}
+	return 5
+}
+
+// messageField 返回生成的 Message 中保存原文的字段名
+func (o Options) messageField() string {
+	if o.MessageField != "" {
+		return o.MessageField
+	}
+	return defaultMessageField
+}
+
+// defaultMessageField go-i18n 的 Message 中保存默认文本的字段名
+const defaultMessageField = "Other"
+
+// validateMessageField 检查字段名是否为导出的 Go 标识符，生成的代码在其他包中设置该字段
+func validateMessageField(field string) error {
+	if field != "" && (!token.IsIdentifier(field) || !token.IsExported(field)) {
+		return fmt.Errorf("字段名 %q 不合法，需为导出的 Go 标识符，如 Other", field)
+	}
+	return nil
+}
+
+// sourceLanguage 返回源码中字符串的语言
+func (o Options) sourceLanguage() string {
+	if o.SourceLang != "" {
+		return o.SourceLang
+	}
+	return defaultSourceLanguage
+}
+
+// detector 返回实际使用的检测规则
+func (o Options) detector() StringDetector {
+	if o.Detector != nil {
+		return o.Detector
+	}
+	return ChineseDetector{}
+}
+
+// 支持的消息ID大小写风格
+const (
+	idCaseSnake  = "snake"
+	idCaseCamel  = "camel"
+	idCasePascal = "pascal"
+)
+
+// 支持的ID生成方式
+const (
+	// idSchemePinyin 由原文开头几个字的拼音生成ID
+	idSchemePinyin = "pinyin"
+	// idSchemeHash 由完整原文的哈希生成ID，如 msg_a1b2c3d4
+	idSchemeHash = "hash"
+)
+
+// 支持的拼音风格
+const (
+	pinyinStyleFirstLetter = "first-letter"
+	pinyinStyleFull        = "full"
+)
+
+// CollectStrings 收集文件中所有待替换的中文字符串，返回原文、位置和将要使用的消息ID
+// 只读取语法树，不修改文件也不输出任何内容
+func CollectStrings(file *ast.File, fset *token.FileSet) []Message {
+	// 默认选项下冲突ID会追加哈希后缀，不会返回错误
+	msgs, _ := collectMessages(file, fset, Options{})
+	return msgs
+}
+
 // 添加一个函数用于收集并输出中文字符串
-func collectAndPrintChineseStrings(file *ast.File) []string {
+func collectAndPrintChineseStrings(file *ast.File, fset *token.FileSet, opts Options) []string {
 	// 初始化为空切片而不是 nil
 	chineseStrings := []string{}
-	
-	ast.Inspect(file, func(n ast.Node) bool {
-		if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
-			// 检查是否是中文字符串
-			if containsChinese(lit.Value) && !isInComment(lit, file, token.NewFileSet()) && !isInStructTagBasicLit(lit, file) {
-				// 去除引号
-				strValue := strings.Trim(lit.Value, "`\"")
-				chineseStrings = append(chineseStrings, strValue)
-			}
-		}
-		return true
-	})
-	
+	for _, c := range collectCandidates(file, fset, opts) {
+		chineseStrings = append(chineseStrings, c.Other)
+	}
+
 	// 输出找到的中文字符串
 	if len(chineseStrings) > 0 {
 		fmt.Println("找到以下中文字符串:")
@@ -43,137 +258,1119 @@ func collectAndPrintChineseStrings(file *ast.File) []string {
 	} else {
 		fmt.Println("未找到中文字符串")
 	}
-	
+
 	return chineseStrings
 }
 
+// 子命令
+const (
+	commandExtract = "extract"
+	commandFix     = "fix"
+	commandCheck   = "check"
+	// commandPreviewIDs 只输出每个字符串将使用的消息ID和冲突，不修改文件
+	commandPreviewIDs = "preview-ids"
+)
+
+// 退出码，CI 可以据此区分结果
+const (
+	// exitOK 没有需要修改的内容，或检查没有发现问题
+	exitOK = 0
+	// exitChanges 转换修改了源码或消息文件，检查发现需要替换的字符串，或代码与消息文件不一致
+	exitChanges = 1
+	// exitUsage 参数错误
+	exitUsage = 2
+	// exitError 解析、转换或读写文件失败
+	exitError = 3
+)
+
 // 修改 main 函数，在转换前输出中文字段
 func main() {
-	if len(os.Args) != 3 {
-		println("Usage: transform <input.go> <output.go>")
-		return
+	if code := run(os.Args[1:]); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// run 执行一次命令行调用，返回进程退出码
+// 第一个参数可以是子命令：extract 只提取消息到消息文件，fix 原地转换文件（处理单个文件时可用 -o 指定输出文件），check 只检查，
+// preview-ids 预览消息ID；
+// 不使用子命令时保持原来的用法
+// 参数可以写在文件之后，-- 之后的都作为文件
+// 参数的默认值可以由环境变量设置，如 STR2GO_PINYIN_STYLE=full、STR2GO_BUNDLE_OUT=locales，命令行中的参数优先
+//
+// 退出码：0 表示转换完成或检查通过；1 表示 check、-diff-only、-l、-missing-against 或 -verify-bundle 发现问题；
+// 2 表示参数错误；3 表示解析、转换或读写文件失败
+func run(args []string) int {
+	var command string
+	if len(args) > 0 {
+		switch args[0] {
+		case commandExtract, commandFix, commandCheck, commandPreviewIDs:
+			command, args = args[0], args[1:]
+		}
+	}
+
+	var opts Options
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.StringVar(&opts.IDScheme, "id-scheme", idSchemePinyin, "自动生成消息ID的方式: pinyin 取开头几个字的拼音，hash 为 msg_ 加完整原文的哈希，不使用拼音")
+	fs.StringVar(&opts.IDCase, "id-case", "", "消息ID大小写风格: snake、camel、pascal，默认直接拼接")
+	fs.StringVar(&opts.PinyinStyle, "pinyin-style", pinyinStyleFirstLetter, "中文转为ID时的拼音风格: first-letter 取首字母，full 取不带声调的完整拼音")
+	fs.StringVar(&opts.IDSeparator, "id-separator", "", "未指定 -id-case 时连接各个拼音的分隔符，默认 first-letter 为空、full 为 _")
+	fs.StringVar(&opts.BundleOut, "bundle-out", "", "将提取的消息合并写入该消息文件，指定目录时使用 active.<源语言>.toml")
+	fs.StringVar(&opts.SourceLang, "source-lang", defaultSourceLanguage, "源码中字符串的语言（BCP 47），写入消息文件名、注释和注册文件")
+	fs.BoolVar(&opts.BundlePerPackage, "bundle-per-package", false, "按包目录分别写入消息文件，文件名取 -bundle-out 的文件名，如 -bundle-out active.zh.toml")
+	fs.BoolVar(&opts.VerifyBundle, "verify-bundle", false, "写入消息文件后检查代码引用的消息ID与消息文件是否一一对应，不一致时以非零状态退出")
+	fs.StringVar(&opts.BundleFormat, "format", bundleFormatTOML, "消息文件格式: toml 为 go-i18n 消息文件，po 为 gettext 模板（.pot）")
+	fs.StringVar(&opts.RegisterOut, "register-out", "", "生成通过 bundle.AddMessages 注册消息的 Go 源文件")
+	fs.StringVar(&opts.MessageField, "message-field", defaultMessageField, "生成的 Message 中保存原文的字段名，用于字段名不是 Other 的自定义消息类型，已在该字段中的字符串不会重复替换")
+	fs.BoolVar(&opts.StrictIDs, "strict-ids", false, "自动生成的消息ID不符合 go-i18n 的要求时报错并给出原文，默认改用 msg_ 加哈希的ID")
+	fs.BoolVar(&opts.MultilineCalls, "multiline-calls", false, "生成的调用分多行输出，LocalizeConfig 的 MessageID、DefaultMessage 等字段各占一行，便于审查差异")
+	fs.BoolVar(&opts.ReattachComments, "reattach-comments", false, "按转换前的注释映射重新放置注释：被替换的表达式内部的注释移到生成的调用之后，新增的导入放在声明的注释之前")
+	fs.BoolVar(&opts.ReuseInFunc, "reuse-in-func", false, "同一函数中多次出现的字符串在第一次出现的语句前赋给局部变量，如 msgNhsj := ...，之后引用该变量")
+	fs.BoolVar(&opts.NoPanic, "no-panic", false, "在 s := \"中文\"、var s = \"中文\" 等位置生成 s, _ := ...Localize(...)，其他位置给出警告并使用 MustLocalize")
+	fs.BoolVar(&opts.LocalizerFromCtx, "localizer-from-ctx", false, "在带有 ctx 参数的函数中生成 i18n.GetLocalizer(ctx).MustLocalize(...)，没有 ctx 时给出警告并使用 i18n.Localizer")
+	fs.BoolVar(&opts.Force, "force", false, "覆盖已存在且内容不同的输出文件；-w 和 fix 总是改写输入文件")
+	fs.BoolVar(&opts.GoImports, "goimports", false, "写入前按 goimports 的规则整理导入分组，文件没有变化时不处理")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "只输出消息文件将产生的新增、更新、删除，不写入输出文件和消息文件")
+	fs.BoolVar(&opts.BundleHeader, "bundle-header", false, "在消息文件开头写入生成工具、时间和源语言的注释")
+	fs.BoolVar(&opts.Prune, "prune", false, "合并消息文件时删除代码中不再引用的ID")
+	fs.BoolVar(&opts.WrapIndexKeys, "wrap-index-keys", false, "同样替换作为索引键或 map 字面量键使用的字符串，如 m[\"中文\"]")
+	fs.BoolVar(&opts.Revert, "revert", false, "将生成的 go-i18n 调用还原为原始字符串")
+	fs.BoolVar(&opts.HTMLAware, "html-aware", false, "生成ID前去除 HTML 片段中的标签，Other 保留原文")
+	fs.BoolVar(&opts.WhitespaceSensitiveIDs, "whitespace-sensitive-ids", false, "首尾带空白的字符串的ID追加 _ws 后缀；默认与去掉空白的字符串按 -unique-suffix 区分")
+	fs.BoolVar(&opts.HTMLTypedAware, "html-typed-aware", false, "生成ID前去除 template.HTML(\"...\") 等转换中字符串的标签，Other 保留原文")
+	fs.BoolVar(&opts.AnnotatePosition, "annotate-position", false, "在生成的 Message 的 Description 中记录源码位置")
+	fs.BoolVar(&opts.SourceAsDescription, "source-as-description", false, "将原文写入 Message 的 Description 作为翻译说明，Other 留空或使用 -other-placeholder，需在消息文件中填写 other，否则 MustLocalize 会 panic，可配合 -no-panic")
+	fs.StringVar(&opts.OtherPlaceholder, "other-placeholder", "", "使用 -source-as-description 时写入 Other 的占位文本")
+	fs.BoolVar(&opts.Traditional2Simplified, "t2s", false, "生成ID前将繁体字转换为简体字，繁简写法共用一个ID，Other 保留原文")
+	sinks := fs.String("sinks", "", "逗号分隔的函数列表，只替换直接作为这些函数参数的字符串，如 fmt.Fprintf,c.JSON")
+	wrapParents := fs.String("wrap-parents", "", "逗号分隔的父节点类型，只替换父节点为这些类型的字符串，如 AssignStmt,ReturnStmt")
+	tagKeys := fs.String("tag-keys", "", "逗号分隔的结构体标签键，提取这些键的中文值到消息文件，如 label,placeholder")
+	fs.BoolVar(&opts.TagIDs, "tag-ids", false, "将 -tag-keys 指定键的中文值替换为消息ID")
+	fs.BoolVar(&opts.WarnPlural, "warn-plural", false, "提示包含数量、可能需要复数形式的字符串")
+	fs.StringVar(&opts.EmbeddedNumbers, "embedded-numbers", "", "处理包含数字的中文字符串，如 \"第1页\": warn 提示后照常替换，skip 提示并跳过")
+	fs.BoolVar(&opts.FlagCommentStrings, "flag-comment-strings", false, "以 TODO 的形式提示包含中文的 // 注释，供人工确认，不修改注释")
+	fs.BoolVar(&opts.UseSpaces, "use-spaces", false, "输出时使用空格缩进")
+	fs.IntVar(&opts.TabWidth, "tabwidth", 8, "缩进宽度")
+	fs.BoolVar(&opts.WrapFlagDefaults, "wrap-flag-defaults", false, "同样替换 flag.String 等参数定义函数中的默认值")
+	flagDefaultArgs := fs.String("flag-default-args", "", "逗号分隔的 函数名:下标，指定自定义参数定义函数的默认值参数，如 cfg.Define:1")
+	wrapArgs := fs.String("wrap-arg", "", "逗号分隔的 函数名:下标，这些函数只替换该下标的参数，如 errorf:1")
+	fs.Int64Var(&opts.MaxSize, "max-size", 0, "跳过超过该字节数的文件，0 表示不限制")
+	idPrefixMap := fs.String("id-prefix-map", "", "TOML 格式的 目录→前缀 映射文件，如 \"services/auth\" = \"auth\"，目录下的文件生成的ID加上 auth_ 前缀，相对路径相对于映射文件所在的目录")
+	fs.BoolVar(&opts.CheckDeps, "check-deps", false, "转换前检查文件的导入和所属的 go.mod，导入或依赖了 go-i18n v1、没有依赖 go-i18n/v2 时给出警告")
+	fs.BoolVar(&opts.Safe, "safe", false, "处理前检查语法，跳过有语法错误的文件并列出错误，不中止整个命令；默认任何文件解析失败时不写入任何结果")
+	fs.BoolVar(&opts.SkipByteConversions, "skip-byte-conversions", false, "不替换 []byte(\"中文\") 转换中的字符串，默认替换")
+	fs.BoolVar(&opts.ConvertSprintf, "convert-sprintf", false, "将 fmt.Sprintf(\"中文 %s\", x) 转换为模板消息，字段名默认为 Arg0、Arg1…，可用 //i18n:args= 注释指定")
+	fs.IntVar(&opts.Limit, "limit", 0, "每个文件最多替换的字符串数量，用于分批迁移，0 表示不限制")
+	fs.IntVar(&opts.IDLength, "id-length", 5, "自动生成ID时最多使用的字符数")
+	fs.StringVar(&opts.UniqueSuffix, "unique-suffix", uniqueSuffixHash, "不同原文生成相同ID时的处理方式: hash 追加哈希后缀，counter 按出现顺序追加 _1、_2，none 报错")
+	logJSON := fs.String("log-json", "", "将警告和错误以 JSON Lines 格式写入该文件")
+	reportMD := fs.String("report-md", "", "处理完成后将修改的文件、提取的字符串、解决的ID冲突和警告汇总写入该 Markdown 文件")
+	write := fs.Bool("w", false, "将结果写回参数中的文件，可以一次处理多个文件")
+	diffOnly := fs.Bool("diff-only", false, "只检查参数中的文件，存在需要替换的字符串时输出并以非零状态退出，不修改文件")
+	missingAgainst := fs.String("missing-against", "", "只检查参数中的文件，输出消息ID不在该消息文件中的字符串，存在时以非零状态退出，不修改文件")
+	list := fs.Bool("l", false, "只列出包含需要替换的字符串的文件路径，存在时以非零状态退出，不修改文件")
+	countChars := fs.Bool("char-count", false, "只统计参数中的文件里待替换的不同字符串及其汉字数量，按文件和合计输出，用于估算翻译费用，不修改文件")
+	patchOut := fs.String("patch-out", "", "不修改参数中的文件，将所有修改以统一 diff 格式写入该补丁文件，可以用 git apply 应用；消息文件照常写入")
+	outputFile := fs.String("o", "", "与 fix 或 -w 一起处理单个文件时，将转换结果写入该文件而不改写输入文件；在消息文件之后写入，两者中的消息ID一致")
+	filesFrom := fs.String("files-from", "", "从该文件读取换行分隔的文件列表，- 表示标准输入，不能用于 <input.go> <output.go> 的用法")
+	if err := applyEnvDefaults(fs); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return exitUsage
+	}
+	switch command {
+	case commandCheck:
+		*diffOnly = true
+	case commandFix:
+		*write = true
+	case commandExtract:
+		if opts.Revert {
+			fmt.Println("参数错误: extract 不能与 -revert 一起使用")
+			return exitUsage
+		}
+		if opts.BundleOut == "" && opts.RegisterOut == "" {
+			fmt.Println("参数错误: extract 需要指定 -bundle-out 或 -register-out")
+			return exitUsage
+		}
+	}
+	extract := command == commandExtract
+	preview := command == commandPreviewIDs
+	// 这些用法接受任意数量的文件
+	fileList := *diffOnly || *write || extract || preview || *missingAgainst != "" || *patchOut != "" || *countChars || *list
+	if *patchOut != "" && (*write || extract || preview || *diffOnly || *missingAgainst != "" || opts.DryRun) {
+		fmt.Println("参数错误: -patch-out 不修改文件，不能与 -w、-diff-only、-missing-against、-dry-run 或子命令一起使用")
+		return exitUsage
+	}
+	if *countChars && (*write || extract || preview || *diffOnly || *missingAgainst != "" || *patchOut != "") {
+		fmt.Println("参数错误: -char-count 只统计字数，不能与 -w、-diff-only、-missing-against、-patch-out 或子命令一起使用")
+		return exitUsage
+	}
+	if *list && (*write || extract || preview || *diffOnly || *missingAgainst != "" || *patchOut != "" || *countChars) {
+		fmt.Println("参数错误: -l 只列出文件，不能与 -w、-diff-only、-missing-against、-patch-out、-char-count 或子命令一起使用")
+		return exitUsage
+	}
+	if *filesFrom != "" && !fileList {
+		fmt.Println("参数错误: -files-from 需要与 -diff-only、-l、-w、-missing-against、-patch-out、-char-count 或子命令一起使用")
+		return exitUsage
+	}
+	if !fileList && len(positional) != 2 {
+		println("Usage: transform [flags] <input.go> <output.go>")
+		println("       transform -w [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform fix [flags] <input.go> -o <output.go> [-bundle-out <bundle.toml>]")
+		println("       transform -diff-only [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform -l [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform -missing-against <bundle.toml> [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform -patch-out <file.patch> [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform -char-count [flags] [-files-from <list.txt>] <file.go>...")
+		println("       transform extract|fix|check|preview-ids [flags] [-files-from <list.txt>] <file.go>...")
+		return exitUsage
+	}
+	if err := validateIDScheme(opts.IDScheme); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if err := validateIDCase(opts.IDCase); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if err := validateMessageField(opts.MessageField); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if opts.SourceAsDescription && opts.AnnotatePosition {
+		fmt.Println("参数错误: -source-as-description 与 -annotate-position 都使用 Description，不能一起使用")
+		return exitUsage
+	}
+	if opts.OtherPlaceholder != "" && !opts.SourceAsDescription {
+		fmt.Println("参数错误: -other-placeholder 需要与 -source-as-description 一起使用")
+		return exitUsage
+	}
+	if err := validatePinyinStyle(opts.PinyinStyle); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	separatorSet := false
+	fs.Visit(func(f *flag.Flag) {
+		separatorSet = separatorSet || f.Name == "id-separator"
+	})
+	if !separatorSet {
+		opts.IDSeparator = defaultIDSeparator(opts.PinyinStyle)
+	}
+	if err := validateIDSeparator(opts.IDSeparator); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if err := validateUniqueSuffix(opts.UniqueSuffix); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if err := validateBundleFormat(opts.BundleFormat); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if err := validateEmbeddedNumbers(opts.EmbeddedNumbers); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	lang, err := validateSourceLanguage(opts.sourceLanguage())
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	opts.SourceLang = lang
+	opts.BundleOut = resolveBundlePath(opts.BundleOut, opts)
+	if opts.BundlePerPackage && opts.BundleOut == "" {
+		fmt.Println("参数错误: -bundle-per-package 需要通过 -bundle-out 指定消息文件名")
+		return exitUsage
+	}
+	if opts.VerifyBundle && (opts.BundleOut == "" || opts.BundleFormat == bundleFormatPO || opts.Revert) {
+		fmt.Println("参数错误: -verify-bundle 需要与 -bundle-out 一起使用，且只支持 toml 格式，不能用于 -revert")
+		return exitUsage
+	}
+	if *idPrefixMap != "" {
+		if opts.IDPrefixes, err = loadIDPrefixes(*idPrefixMap); err != nil {
+			fmt.Printf("读取ID前缀映射失败: %v\n", err)
+			return exitError
+		}
+	}
+	opts.Sinks = splitList(*sinks)
+	opts.TagKeys = splitList(*tagKeys)
+	opts.WrapParents = splitList(*wrapParents)
+	if err := validateWrapParents(opts.WrapParents); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	positions, err := parseArgPositions(*flagDefaultArgs)
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	opts.FlagDefaultArgs = positions
+	opts.WrapArgs, err = parseArgPositions(*wrapArgs)
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return exitUsage
+	}
+	if *logJSON != "" {
+		f, err := os.Create(*logJSON)
+		if err != nil {
+			fmt.Printf("创建日志文件失败: %v\n", err)
+			return exitError
+		}
+		defer f.Close()
+		opts.DiagnosticLog = f
+	}
+	var diags []Diagnostic
+	if *reportMD != "" {
+		opts.recorded = &diags
+	}
+	// writeMigrationReport 按需写入迁移报告，written 表示源码文件是否被改写
+	writeMigrationReport := func(files []*ast.File, fset *token.FileSet, msgs []Message, collisions int, written bool) error {
+		if *reportMD == "" {
+			return nil
+		}
+		return writeReport(*reportMD, newMigrationReport(files, fset, msgs, collisions, written, diags))
+	}
+
+	var inputs, outputs []string
+	if fileList {
+		inputs = positional
+		if *filesFrom != "" {
+			listed, err := loadFileList(*filesFrom)
+			if err != nil {
+				fmt.Printf("读取文件列表失败: %v\n", err)
+				return exitError
+			}
+			inputs = append(inputs, listed...)
+		}
+		outputs = inputs
+	} else {
+		inputs = positional[:1]
+		outputs = positional[1:]
+	}
+	if *outputFile != "" {
+		if !*write || len(inputs) != 1 {
+			fmt.Println("参数错误: -o 需要与 fix 或 -w 一起使用，且只能处理一个文件")
+			return exitUsage
+		}
+		outputs = []string{*outputFile}
+	}
+
+	if *diffOnly {
+		found, err := checkFiles(os.Stdout, inputs, opts)
+		if err != nil {
+			fmt.Printf("检查文件失败: %v\n", err)
+			return exitError
+		}
+		if found {
+			return exitChanges
+		}
+		return exitOK
+	}
+	if *list {
+		found, err := listFiles(os.Stdout, inputs, opts)
+		if err != nil {
+			fmt.Printf("检查文件失败: %v\n", err)
+			return exitError
+		}
+		if found {
+			return exitChanges
+		}
+		return exitOK
+	}
+	if preview {
+		if _, err := previewIDs(os.Stdout, inputs, opts); err != nil {
+			fmt.Printf("预览消息ID失败: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+	if *countChars {
+		if _, err := countCharacters(os.Stdout, inputs, opts); err != nil {
+			fmt.Printf("统计字数失败: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+	if *missingAgainst != "" {
+		found, err := findMissingMessages(os.Stdout, inputs, *missingAgainst, opts)
+		if err != nil {
+			fmt.Printf("检查文件失败: %v\n", err)
+			return exitError
+		}
+		if found {
+			return exitChanges
+		}
+		return exitOK
 	}
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
 
+	// 先解析全部文件，任何一个文件出错时不写入任何结果
 	fset := token.NewFileSet()
-	file, err := parser.ParseFile(fset, inputFile, nil, parser.ParseComments)
+	var files []*ast.File
+	var paths []string
+	for i, inputFile := range inputs {
+		file, skip, err := parseInputFile(fset, inputFile, opts)
+		if err != nil {
+			fmt.Printf("解析文件失败: %v\n", err)
+			return exitError
+		}
+		if skip != "" {
+			fmt.Printf("警告: 跳过 %s: %s\n", inputFile, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: inputFile}, "跳过: "+skip))
+			continue
+		}
+		files = append(files, file)
+		paths = append(paths, outputs[i])
+	}
+	if len(files) == 0 {
+		return exitOK
+	}
+
+	// 已有调用中的消息与新提取的消息一起写入消息文件和注册文件，需在转换前收集
+	var existing []Message
+	if (opts.BundleOut != "" || opts.RegisterOut != "") && !opts.Revert {
+		existing = collectWrappedMessages(files, fset, opts)
+	}
+
+	var msgs []Message
+	collisions := 0
+	if opts.Revert {
+		// 还原文件，并输出不再被引用的消息ID
+		for _, file := range files {
+			printRevertedIDs(revert(file, fset, opts))
+		}
+	} else {
+		// 在转换前收集并输出中文字符串
+		for _, file := range files {
+			fmt.Printf("正在分析文件: %s\n", fset.File(file.Pos()).Name())
+			collectAndPrintChineseStrings(file, fset, opts)
+			if opts.WarnPlural {
+				printPluralWarnings(os.Stdout, findPluralWarnings(file, fset, opts), opts)
+			}
+			if opts.EmbeddedNumbers != "" {
+				printNumberWarnings(os.Stdout, findNumberWarnings(file, fset, opts), opts)
+			}
+			if opts.FlagCommentStrings {
+				printCommentStrings(os.Stdout, findCommentStrings(file, fset), opts)
+			}
+		}
+		if opts.CheckDeps {
+			printDependencyWarnings(os.Stdout, findDependencyWarnings(files, fset), opts)
+		}
+
+		// 转换会修改语法树，需要先统计冲突；出错时由下面的转换报告
+		if *reportMD != "" {
+			collisions, _ = countResolvedCollisions(files, fset, opts)
+		}
+
+		// 转换文件
+		var err error
+		msgs, err = transformFiles(files, fset, opts)
+		if err != nil {
+			fmt.Printf("转换文件失败: %v\n", err)
+			var collision *CollisionError
+			if errors.As(err, &collision) {
+				opts.logDiagnostic(newDiagnostic(severityError, codeIDCollision, collision.Second.Position, err.Error()))
+			}
+			var invalid *InvalidIDError
+			if errors.As(err, &invalid) {
+				opts.logDiagnostic(newDiagnostic(severityError, codeInvalidID, invalid.Position, err.Error()))
+			}
+			return exitError
+		}
+	}
+
+	if opts.RegisterOut != "" {
+		for _, file := range files[1:] {
+			if file.Name.Name != files[0].Name.Name {
+				fmt.Printf("参数错误: -register-out 要求所有文件属于同一个包，%s 与 %s 不同\n", file.Name.Name, files[0].Name.Name)
+				return exitUsage
+			}
+		}
+	}
+
+	if opts.DryRun {
+		if opts.BundleOut != "" && !opts.Revert {
+			for _, target := range bundleTargets(opts.BundleOut, files, fset, append(msgs, existing...), opts) {
+				if err := previewBundle(os.Stdout, target.Path, target.Msgs, opts); err != nil {
+					fmt.Printf("读取消息文件失败: %v\n", err)
+					return exitError
+				}
+			}
+		}
+		if err := writeMigrationReport(files, fset, msgs, collisions, false); err != nil {
+			fmt.Printf("写入迁移报告失败: %v\n", err)
+			return exitError
+		}
+		return exitOK
+	}
+
+	// -o 的输出在消息文件和注册文件之后写入，它们写入失败时不会留下引用了缺失消息的源码
+	var output []byte
+	// 源码或消息文件的内容有变化时以 exitChanges 退出
+	changed := false
+	// extract 只更新消息文件，不修改源码
+	if !extract {
+		var patch bytes.Buffer
+		patched := 0
+		for i, file := range files {
+			data, err := renderOutputFile(paths[i], fset, file, opts)
+			if err != nil {
+				fmt.Printf("写入输出文件失败: %v\n", err)
+				return exitError
+			}
+			name := fset.File(file.Pos()).Name()
+			original, err := os.ReadFile(name)
+			if err != nil {
+				fmt.Printf("读取文件失败: %v\n", err)
+				return exitError
+			}
+			if !bytes.Equal(original, data) {
+				changed = true
+			}
+
+			// 输出补丁时不修改文件，只记录与原文件的差异
+			if *patchOut != "" {
+				if writeUnifiedDiff(&patch, patchPath(name), original, data) {
+					patched++
+				}
+				continue
+			}
+
+			// 原地改写时输出文件就是输入文件，总是覆盖
+			if (!*write || *outputFile != "") && paths[i] != inputs[i] {
+				if err := checkOverwrite(paths[i], data, opts); err != nil {
+					fmt.Printf("写入输出文件失败: %v\n", err)
+					return exitError
+				}
+			}
+			if *outputFile != "" {
+				output = data
+				continue
+			}
+			if err := os.WriteFile(paths[i], data, 0644); err != nil {
+				fmt.Printf("写入输出文件失败: %v\n", err)
+				return exitError
+			}
+		}
+		if *patchOut != "" {
+			if err := os.WriteFile(*patchOut, patch.Bytes(), 0644); err != nil {
+				fmt.Printf("写入补丁失败: %v\n", err)
+				return exitError
+			}
+			fmt.Printf("补丁 %s: %d 个文件有修改\n", *patchOut, patched)
+		}
+	}
+
+	var targets []bundleTarget
+	if opts.BundleOut != "" && !opts.Revert {
+		targets = bundleTargets(opts.BundleOut, files, fset, append(msgs, existing...), opts)
+		for _, target := range targets {
+			bundleChanged, err := writeBundle(target.Path, target.Msgs, opts)
+			if err != nil {
+				fmt.Printf("写入消息文件失败: %v\n", err)
+				return exitError
+			}
+			changed = changed || bundleChanged
+		}
+	}
+	if opts.RegisterOut != "" && !opts.Revert {
+		if err := writeRegisterFile(opts.RegisterOut, files[0].Name.Name, opts.sourceLanguage(), append(msgs, existing...)); err != nil {
+			fmt.Printf("写入注册文件失败: %v\n", err)
+			return exitError
+		}
+	}
+	if output != nil {
+		if err := os.WriteFile(*outputFile, output, 0644); err != nil {
+			fmt.Printf("写入输出文件失败: %v\n", err)
+			return exitError
+		}
+	}
+	if opts.VerifyBundle {
+		for _, target := range targets {
+			mismatch, err := verifyBundle(target.Path, target.Files, fset, target.Msgs)
+			if err != nil {
+				fmt.Printf("读取消息文件失败: %v\n", err)
+				return exitError
+			}
+			if !mismatch.empty() {
+				printBundleMismatch(os.Stdout, target.Path, mismatch)
+				return exitChanges
+			}
+		}
+	}
+	if err := writeMigrationReport(files, fset, msgs, collisions, !extract); err != nil {
+		fmt.Printf("写入迁移报告失败: %v\n", err)
+		return exitError
+	}
+	if changed {
+		return exitChanges
+	}
+	return exitOK
+}
+
+// renderOutputFile 按配置输出文件内容
+// opts.GoImports 为 true 且结果与原文件不同时，先按 goimports 的规则整理导入分组
+func renderOutputFile(path string, fset *token.FileSet, file *ast.File, opts Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := printFile(&buf, fset, file, opts); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+
+	if opts.GoImports {
+		original, err := os.ReadFile(fset.File(file.Pos()).Name())
+		if err != nil || !bytes.Equal(original, data) {
+			return formatImports(path, data, opts)
+		}
+	}
+	return data, nil
+}
+
+// checkOverwrite 检查能否写入输出文件：文件不存在或内容与将要写入的内容相同时可以写入，
+// 否则需要 opts.Force，避免误覆盖其他文件
+func checkOverwrite(path string, data []byte, opts Options) error {
+	if opts.Force {
+		return nil
+	}
+	existing, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
 	if err != nil {
-		fmt.Printf("解析文件失败: %v\n", err)
-		return
+		return err
 	}
-	
-	// 在转换前收集并输出中文字符串
-	fmt.Printf("正在分析文件: %s\n", inputFile)
-	collectAndPrintChineseStrings(file)
-	
-	// 转换文件
-	transform(file, fset)
+	if !bytes.Equal(existing, data) {
+		return fmt.Errorf("输出文件 %s 已存在且内容不同，使用 -force 覆盖", path)
+	}
+	return nil
+}
 
-	out, err := os.Create(outputFile)
+// formatImports 使用 goimports 整理导入：排序并将标准库与第三方包分组
+// 只整理已有的导入，不会查找或删除导入，结果不依赖本地环境
+func formatImports(path string, src []byte, opts Options) ([]byte, error) {
+	tabWidth := opts.TabWidth
+	if tabWidth <= 0 {
+		tabWidth = 8
+	}
+	return imports.Process(path, src, &imports.Options{
+		Comments:   true,
+		TabIndent:  !opts.UseSpaces,
+		TabWidth:   tabWidth,
+		FormatOnly: true,
+	})
+}
+
+// printFile 按配置的缩进方式输出文件
+func printFile(w io.Writer, fset *token.FileSet, file *ast.File, opts Options) error {
+	config := printer.Config{Tabwidth: opts.TabWidth}
+	if config.Tabwidth <= 0 {
+		config.Tabwidth = 8
+	}
+	if opts.UseSpaces {
+		config.Mode = printer.UseSpaces
+	}
+	if !opts.MultilineCalls {
+		return config.Fprint(w, fset, file)
+	}
+
+	// 先打印一次，拆分生成的调用后重新解析再打印，由 go/printer 对齐字段和缩进
+	var buf bytes.Buffer
+	if err := config.Fprint(&buf, fset, file); err != nil {
+		return err
+	}
+	printedSet := token.NewFileSet()
+	printed, err := parser.ParseFile(printedSet, "", buf.Bytes(), parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	src := splitGeneratedConfigs(file, buf.Bytes(), printed, printedSet)
+	splitSet := token.NewFileSet()
+	split, err := parser.ParseFile(splitSet, "", src, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+	return config.Fprint(w, splitSet, split)
+}
+
+// TransformSource 解析并转换一段 Go 源码，返回转换后的源码和被替换的消息
+// 输出与命令行写入的文件相同，缩进受 opts.UseSpaces 和 opts.TabWidth 影响；
+// 消息的源码位置中没有文件名
+func TransformSource(src []byte, opts Options) ([]byte, []Message, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	msgs, err := transformTo(&buf, file, fset, opts)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-	defer out.Close()
+	return buf.Bytes(), msgs, nil
+}
+
+// TransformTo 转换已解析的文件并将结果写入 w，如 bytes.Buffer、gzip.Writer 或网络连接
+// 输出与 TransformSource 相同；转换失败时不写入任何内容，语法树也不会被修改，
+// 写入 w 失败时语法树已经转换，可能已写入部分内容
+func TransformTo(w io.Writer, file *ast.File, fset *token.FileSet, opts Options) error {
+	_, err := transformTo(w, file, fset, opts)
+	return err
+}
 
-	if err := printer.Fprint(out, fset, file); err != nil {
-		panic(err)
+// transformTo 与 TransformTo 相同，同时返回被替换的消息
+func transformTo(w io.Writer, file *ast.File, fset *token.FileSet, opts Options) ([]Message, error) {
+	msgs, err := transform(file, fset, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := printFile(w, fset, file, opts); err != nil {
+		return nil, err
 	}
+	return msgs, nil
+}
+
+// transform 将文件中的中文字符串替换为 go-i18n 调用，返回被替换的消息
+// 先收集文件中的全部字符串统一计算ID，再按计算结果替换，ID不受字符串出现的顺序影响
+// 无法确定唯一的ID时返回错误，此时语法树不会被修改
+func transform(file *ast.File, fset *token.FileSet, opts Options) ([]Message, error) {
+	return transformFiles([]*ast.File{file}, fset, opts)
 }
 
-func transform(file *ast.File, fset *token.FileSet) {
+// transformFiles 转换多个文件，消息ID根据全部文件中的字符串统一计算，
+// 不同文件中的不同原文不会得到相同的ID
+func transformFiles(files []*ast.File, fset *token.FileSet, opts Options) ([]Message, error) {
+	var cands []candidate
+	fileCands := make([][]candidate, len(files))
+	for i, file := range files {
+		fileCands[i] = collectCandidates(file, fset, opts)
+		cands = append(cands, fileCands[i]...)
+	}
+	printAnnotationWarnings(cands, opts)
+	// 已有调用使用的ID需要预留，否则新原文可能得到相同的ID并覆盖消息文件中的原文
+	ids, err := planMessageIDs(append(cands, existingCandidates(files, fset, opts)...), opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// 自动生成的ID按文件所在目录加上前缀，加上前缀后仍需保证不同原文的ID不同
+	fileIDs := make([]map[string]string, len(files))
+	annotated := annotatedTexts(cands)
+	for i, file := range files {
+		fileIDs[i] = applyIDPrefix(ids, annotated, fset.File(file.Pos()).Name(), opts)
+	}
+	if len(opts.IDPrefixes) > 0 {
+		if err := checkPrefixedIDs(fileCands, fileIDs, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	var msgs []Message
+	for i, file := range files {
+		msgs = append(msgs, transformWithIDs(file, fset, opts, fileIDs[i])...)
+	}
+	return msgs, nil
+}
+
+// transformWithIDs 按预先计算的 原文→消息ID 映射替换文件中的字符串，返回被替换的消息
+// 映射中不存在的原文使用自动生成的ID，注释指定的ID优先于映射
+func transformWithIDs(file *ast.File, fset *token.FileSet, opts Options, ids map[string]string) []Message {
 	needsImport := false
+	var msgs []Message
+	annotations := collectIDAnnotations(file, fset)
+	var snap commentSnapshot
+	if opts.ReattachComments {
+		snap = snapshotComments(file, fset)
+	}
+
+	// 分批替换时按源码位置选取前 opts.Limit 个字符串，转换的 fmt.Sprintf 与普通字符串一起排序
+	var limited map[int]bool
+	if opts.Limit > 0 {
+		limited = firstCandidates(collectCandidates(file, fset, opts), opts.Limit)
+	}
+	sprintfConverted := false
+	// 可以在函数内复用的调用及其消息ID
+	reusable := map[*ast.CallExpr]string{}
+
+	// 生成的调用按文件导入 go-i18n 的方式引用包名
+	qualifier := i18nQualifier(file)
+	// 按需把生成的调用改为从 ctx 中取出 Localizer
+	scopes := collectCtxScopes(file)
+	useLocalizer := func(call *ast.CallExpr, at token.Pos) *ast.CallExpr {
+		if !opts.LocalizerFromCtx {
+			return call
+		}
+		if !scopes.contains(at) {
+			pos := fset.Position(at)
+			message := "所在函数没有 ctx 参数，使用 i18n.Localizer"
+			fmt.Printf("警告: %s: %s\n", pos, message)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeNoCtx, pos, message))
+			return call
+		}
+		call.Fun.(*ast.SelectorExpr).X = newCtxLocalizer()
+		return call
+	}
+	// 按需改为返回 error 的 Localize，只用于可以多接收一个返回值的位置
+	useLocalize := func(cursor *astutil.Cursor, call *ast.CallExpr, at token.Pos) *ast.CallExpr {
+		if !opts.NoPanic {
+			return call
+		}
+		addBlank, ok := errorResultSlot(cursor)
+		if !ok {
+			pos := fset.Position(at)
+			message := "所在位置无法接收 error 返回值，使用 MustLocalize"
+			fmt.Printf("警告: %s: %s\n", pos, message)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeNoPanic, pos, message))
+			return call
+		}
+		addBlank()
+		call.Fun.(*ast.SelectorExpr).Sel.Name = "Localize"
+		return call
+	}
+	walkSprintfs(file, fset, opts, func(cursor *astutil.Cursor, msg sprintfMessage) {
+		pos := fset.Position(msg.Format.Pos())
+		msgID, ok := ids[msg.Template]
+		if !ok {
+			msgID = generateMessageID(strconv.Quote(msg.Template), opts)
+		}
+		annotatedID, annotated := annotations.take(pos.Line)
+		if opts.Limit > 0 && !limited[pos.Offset] {
+			return
+		}
+		needsImport = true
+		sprintfConverted = true
+		if annotated && validateMessageID(annotatedID) == nil {
+			msgID = annotatedID
+		}
+		other, description := messageText(msg.Template, pos, opts)
+		msgs = append(msgs, newMessage(msgID, other, description, pos, opts))
+
+		template := msg
+		template.Template = other
+		call := useLocalize(cursor, useLocalizer(newTemplateLocalizeCall(msgID, description, template, opts.messageField()), msg.Format.Pos()), msg.Format.Pos())
+		cursor.Replace(placeAt(qualifyI18n(call, qualifier), cursor.Node().Pos(), cursor.Node().End()))
+	})
+
+	walkStrings(file, opts, func(cursor *astutil.Cursor, lit *ast.BasicLit) {
+		source := unquoteLit(lit)
+		msgID, ok := ids[source]
+		if !ok {
+			msgID = generateMessageID(lit.Value, opts)
+		}
+		// 注释中指定的ID已在收集阶段校验过，这里只使用合法的ID
+		// 超出数量限制的字符串也要取走注释，避免被下一行的字符串误用
+		annotatedID, annotated := annotations.take(fset.Position(lit.Pos()).Line)
+		if opts.Limit > 0 && !limited[fset.Position(lit.Pos()).Offset] {
+			return
+		}
+		needsImport = true
+		if annotated && validateMessageID(annotatedID) == nil {
+			msgID = annotatedID
+		}
+		other, description := messageText(source, fset.Position(lit.Pos()), opts)
+		msgs = append(msgs, newMessage(msgID, other, description, fset.Position(lit.Pos()), opts))
+
+		call := useLocalize(cursor, useLocalizer(newLocalizeCall(msgID, other, description, opts.messageField()), lit.Pos()), lit.Pos())
+		// 改为 Localize 的调用在赋值语句中多接收了 error，不能替换为变量
+		if opts.ReuseInFunc && call.Fun.(*ast.SelectorExpr).Sel.Name == "MustLocalize" {
+			reusable[call] = msgID
+		}
+		cursor.Replace(placeAt(qualifyI18n(call, qualifier), lit.Pos(), lit.End()))
+	})
+
+	// 标签中无法调用函数，只记录消息，按需把值替换为消息ID
+	rewriteTagMessages(file, opts.TagKeys, func(lit *ast.BasicLit, value string) string {
+		msgID, ok := ids[value]
+		if !ok {
+			msgID = generateMessageID(strconv.Quote(value), opts)
+		}
+		other, description := messageText(value, fset.Position(lit.Pos()), opts)
+		msgs = append(msgs, newMessage(msgID, other, description, fset.Position(lit.Pos()), opts))
+		if opts.TagIDs {
+			return msgID
+		}
+		return value
+	})
+
+	// 与 collectCandidates 一样按源码位置返回消息
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Position.Offset < msgs[j].Position.Offset })
+
+	reuseInFunctions(file, reusable)
+	if needsImport {
+		ensureI18nImport(file, fset, qualifier)
+	}
+	// 转换 fmt.Sprintf 后 fmt 可能不再被使用
+	if sprintfConverted && !astutil.UsesImport(file, "fmt") {
+		astutil.DeleteImport(fset, file, "fmt")
+	}
+	if opts.ReattachComments {
+		reattachComments(file, snap)
+	}
+	return msgs
+}
+
+// messageText 返回生成的 Message 中的 Other 和 Description
+// opts.SourceAsDescription 为 true 时原文写入 Description，Other 为占位文本；
+// 否则 Other 为原文，opts.AnnotatePosition 为 true 时 Description 为源码位置
+func messageText(source string, pos token.Position, opts Options) (other, description string) {
+	if opts.SourceAsDescription {
+		return opts.OtherPlaceholder, source
+	}
+	if opts.AnnotatePosition {
+		description = positionDescription(pos)
+	}
+	return source, description
+}
+
+// newMessage 记录被替换的消息，只有原文作为说明时才把说明写入消息文件
+func newMessage(id, other, description string, pos token.Position, opts Options) Message {
+	msg := Message{ID: id, Other: other, Position: pos}
+	if opts.SourceAsDescription {
+		msg.Description = description
+	}
+	return msg
+}
+
+// positionDescription 将源码位置格式化为 文件:行号
+func positionDescription(pos token.Position) string {
+	return token.Position{Filename: pos.Filename, Line: pos.Line}.String()
+}
+
+// walkStrings 遍历文件中需要替换的字符串，对每个字符串调用 visit
+// visit 可以通过 cursor 替换当前节点；由 + 连接的字符串常量会合并为一个字面量传入
+func walkStrings(file *ast.File, opts Options, visit func(cursor *astutil.Cursor, lit *ast.BasicLit)) {
+	detector := opts.detector()
+	qualifier := i18nQualifier(file)
+	flagPkgs := flagPackageNames(file)
+	inMessage := map[ast.Node]bool{}
 
 	pre := func(cursor *astutil.Cursor) bool {
 		n := cursor.Node()
 
-		lit, ok := n.(*ast.BasicLit)
-		if !ok || lit.Kind != token.STRING {
+		// 常量的值必须是常量表达式，替换为函数调用后无法编译，包括类型化的字符串常量
+		if decl, ok := n.(*ast.GenDecl); ok && decl.Tok == token.CONST {
+			return false
+		}
+		// go-i18n 的 LocalizeConfig 和 Message 中的字面量属于消息本身，如消息ID、原文和 Description，不是展示文本；
+		// 只有 TemplateData 中的值是普通数据，照常处理
+		if lit, ok := n.(*ast.CompositeLit); ok && (isI18nType(lit.Type, qualifier, "LocalizeConfig") || isI18nType(lit.Type, qualifier, "Message")) {
+			for _, elt := range lit.Elts {
+				key, value, ok := keyValue(elt)
+				switch {
+				case !ok:
+					inMessage[elt] = true
+				case key != "TemplateData":
+					inMessage[value] = true
+				}
+			}
+		}
+		if inMessage[n] {
+			return false
+		}
+
+		lit, folded := stringLiteral(n)
+		if lit == nil {
 			return true
 		}
+		// 合并后的字面量作为整体处理，不再进入其中的各个操作数
+		descend := !folded
 
 		if isInStructTag(cursor) {
-			return true
+			return descend
 		}
 
-		if isWrappedByI18nT(cursor) {
-			return true
+		if isWrappedByI18nT(cursor, opts.messageField()) {
+			return descend
 		}
 
-		if !hasChinese.MatchString(lit.Value) {
-			return true
+		// 索引键和 map 字面量的键替换后会与原有的键不一致，默认不处理
+		if !opts.WrapIndexKeys && (isIndexKey(cursor) || isCompositeKey(cursor)) {
+			return descend
+		}
+
+		// []byte("中文") 常用于二进制数据而非展示文本，可以选择不处理
+		if opts.SkipByteConversions && isByteConversion(cursor) {
+			return descend
 		}
 
 		// 注释中的字符串不应该被处理
-		if isInComment(lit, file, fset) {
-			return true
+		if isInComment(n, file) {
+			return descend
 		}
 
-		needsImport = true
+		// 参数的默认值常作为特殊标记使用，默认不处理，说明文字仍会被替换
+		if !opts.WrapFlagDefaults && isFlagDefault(cursor, opts.FlagDefaultArgs, flagPkgs) {
+			return descend
+		}
 
-		// 生成消息ID
-		msgID := generateMessageID(lit.Value)
+		// 转换为模板消息的 fmt.Sprintf 格式字符串由 walkSprintfs 处理
+		if opts.ConvertSprintf && isSprintfFormat(cursor) {
+			return descend
+		}
 
-		// 创建符合 go-i18n 格式的调用
-		// 使用 i18n.Localizer.MustLocalize 和 &i18n.LocalizeConfig
-		newNode := &ast.CallExpr{
-			Fun: &ast.SelectorExpr{
-				X: &ast.SelectorExpr{
-					X:   ast.NewIdent("i18n"),
-					Sel: ast.NewIdent("Localizer"),
-				},
-				Sel: ast.NewIdent("MustLocalize"),
+		if !wrapAllowed(cursor, lit, detector, opts) {
+			return descend
+		}
+
+		visit(cursor, lit)
+		return descend
+	}
+
+	astutil.Apply(file, pre, nil)
+}
+
+// wrapAllowed 按 -sinks、-wrap-parents、-wrap-args、检测规则和 -embedded-numbers 检查 cursor 处的节点能否替换
+// lit 为节点中的字符串，普通字符串为节点本身，fmt.Sprintf 调用为其格式字符串
+func wrapAllowed(cursor *astutil.Cursor, lit *ast.BasicLit, detector StringDetector, opts Options) bool {
+	if len(opts.Sinks) > 0 && !isSinkArgument(cursor, opts.Sinks) {
+		return false
+	}
+
+	if len(opts.WrapParents) > 0 && !isAllowedParent(cursor, opts.WrapParents) {
+		return false
+	}
+
+	if len(opts.WrapArgs) > 0 && !isWrappedArgument(cursor, opts.WrapArgs) {
+		return false
+	}
+
+	if !detector.ShouldWrap(lit, cursor) {
+		return false
+	}
+
+	// 数字通常是运行时的值，可以选择留给人工改为模板消息
+	return opts.EmbeddedNumbers != embeddedNumbersSkip || !hasEmbeddedNumber(unquoteLit(lit))
+}
+
+// newLocalizeCall 创建符合 go-i18n 格式的调用
+// 使用 i18n.Localizer.MustLocalize 和 &i18n.LocalizeConfig，description 不为空时写入 Message 的 Description，
+// 原文写入 Message 的 field 字段，go-i18n 中为 Other
+// 所有节点都是新建的，Other 不复用原字符串的节点，以免与原节点共享位置
+func newLocalizeCall(msgID, other, description, field string) *ast.CallExpr {
+	message := []ast.Expr{
+		&ast.KeyValueExpr{
+			Key:   ast.NewIdent("ID"),
+			Value: &ast.BasicLit{Kind: token.STRING, Value: `"` + msgID + `"`},
+		},
+	}
+	if description != "" {
+		message = append(message, &ast.KeyValueExpr{
+			Key:   ast.NewIdent("Description"),
+			Value: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(description)},
+		})
+	}
+	message = append(message, &ast.KeyValueExpr{
+		Key:   ast.NewIdent(field),
+		Value: &ast.BasicLit{Kind: token.STRING, Value: quoteOther(other)},
+	})
+
+	return &ast.CallExpr{
+		Fun: &ast.SelectorExpr{
+			X: &ast.SelectorExpr{
+				X:   ast.NewIdent("i18n"),
+				Sel: ast.NewIdent("Localizer"),
 			},
-			Args: []ast.Expr{
-				&ast.UnaryExpr{
-					Op: token.AND,
-					X: &ast.CompositeLit{
-						Type: &ast.SelectorExpr{
-							X:   ast.NewIdent("i18n"),
-							Sel: ast.NewIdent("LocalizeConfig"),
+			Sel: ast.NewIdent("MustLocalize"),
+		},
+		Args: []ast.Expr{
+			&ast.UnaryExpr{
+				Op: token.AND,
+				X: &ast.CompositeLit{
+					Type: &ast.SelectorExpr{
+						X:   ast.NewIdent("i18n"),
+						Sel: ast.NewIdent("LocalizeConfig"),
+					},
+					Elts: []ast.Expr{
+						&ast.KeyValueExpr{
+							Key:   ast.NewIdent("MessageID"),
+							Value: &ast.BasicLit{Kind: token.STRING, Value: `"` + msgID + `"`},
 						},
-						Elts: []ast.Expr{
-							&ast.KeyValueExpr{
-								Key:   ast.NewIdent("MessageID"),
-								Value: &ast.BasicLit{Kind: token.STRING, Value: `"` + msgID + `"`},
-							},
-							&ast.KeyValueExpr{
-								Key: ast.NewIdent("DefaultMessage"),
-								Value: &ast.UnaryExpr{
-									Op: token.AND,
-									X: &ast.CompositeLit{
-										Type: &ast.SelectorExpr{
-											X:   ast.NewIdent("i18n"),
-											Sel: ast.NewIdent("Message"),
-										},
-										Elts: []ast.Expr{
-											&ast.KeyValueExpr{
-												Key:   ast.NewIdent("ID"),
-												Value: &ast.BasicLit{Kind: token.STRING, Value: `"` + msgID + `"`},
-											},
-											&ast.KeyValueExpr{
-												Key:   ast.NewIdent("Other"),
-												Value: lit,
-											},
-										},
+						&ast.KeyValueExpr{
+							Key: ast.NewIdent("DefaultMessage"),
+							Value: &ast.UnaryExpr{
+								Op: token.AND,
+								X: &ast.CompositeLit{
+									Type: &ast.SelectorExpr{
+										X:   ast.NewIdent("i18n"),
+										Sel: ast.NewIdent("Message"),
 									},
+									Elts: message,
 								},
 							},
 						},
 					},
 				},
 			},
+		},
+	}
+}
+
+// stringLiteral 返回节点对应的字符串字面量
+// 全部由字符串常量通过 + 连接的表达式会被合并为一个字面量，此时 folded 为 true
+func stringLiteral(n ast.Node) (lit *ast.BasicLit, folded bool) {
+	switch n := n.(type) {
+	case *ast.BasicLit:
+		if n.Kind == token.STRING {
+			return n, false
+		}
+	case *ast.BinaryExpr:
+		if s, ok := foldStringConcat(n); ok {
+			return &ast.BasicLit{ValuePos: n.Pos(), Kind: token.STRING, Value: strconv.Quote(s)}, true
 		}
+	}
+	return nil, false
+}
 
-		cursor.Replace(newNode)
-		return true
+// foldStringConcat 计算由 + 连接的字符串常量的值，存在非字符串常量操作数时返回 false
+func foldStringConcat(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+		s, err := strconv.Unquote(e.Value)
+		return s, err == nil
+	case *ast.ParenExpr:
+		return foldStringConcat(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+		x, ok := foldStringConcat(e.X)
+		if !ok {
+			return "", false
+		}
+		y, ok := foldStringConcat(e.Y)
+		if !ok {
+			return "", false
+		}
+		return x + y, true
 	}
+	return "", false
+}
 
-	astutil.Apply(file, pre, nil)
+// unquoteLit 返回字符串字面量解码后的内容
+func unquoteLit(lit *ast.BasicLit) string {
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return strings.Trim(lit.Value, "`\"")
+	}
+	return s
+}
 
-	if needsImport {
-		ensureI18nImport(file, fset)
+// quoteOther 将消息文本重新编码为字符串字面量
+// 文本可以用反引号表示（不含反引号和换行等控制字符）且包含双引号或多个反斜杠时使用反引号，
+// 避免大量转义，否则使用 strconv.Quote
+func quoteOther(s string) string {
+	if !strconv.CanBackquote(s) {
+		return strconv.Quote(s)
+	}
+	if strings.Contains(s, `"`) || strings.Count(s, `\`) >= 2 {
+		return "`" + s + "`"
 	}
+	return strconv.Quote(s)
 }
 
 func isInStructTag(cursor *astutil.Cursor) bool {
@@ -190,14 +1387,152 @@ func isInStructTag(cursor *astutil.Cursor) bool {
 	return field.Tag == cursor.Node()
 }
 
-func isWrappedByI18nT(cursor *astutil.Cursor) bool {
-	// 检查当前节点是否是字符串字面量
-	_, ok := cursor.Node().(*ast.BasicLit)
+// isIndexKey 检查当前节点是否是索引表达式中的键，如 m["键"]
+func isIndexKey(cursor *astutil.Cursor) bool {
+	index, ok := cursor.Parent().(*ast.IndexExpr)
 	if !ok {
 		return false
 	}
-	
-	// 检查父节点是否是 KeyValueExpr，且 Key 是 "Other"
+	return index.Index == cursor.Node()
+}
+
+// htmlTypedConversions 内容为 HTML 片段的类型，按调用处的写法匹配
+var htmlTypedConversions = map[string]bool{
+	"template.HTML":     true,
+	"template.HTMLAttr": true,
+}
+
+// isHTMLTypedConversion 检查当前节点是否是 HTML 类型转换的参数，如 template.HTML("<b>中文</b>")
+// 替换后的调用返回 string，转换仍然合法
+func isHTMLTypedConversion(cursor *astutil.Cursor) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || call.Args[0] != cursor.Node() {
+		return false
+	}
+	return htmlTypedConversions[calleeName(call)]
+}
+
+// isCompositeKey 检查当前节点是否是复合字面量中的键，如 gin.H{"键": "值"} 中的 "键"
+// 字符串只能作为 map 字面量的键，值不受影响
+func isCompositeKey(cursor *astutil.Cursor) bool {
+	kv, ok := cursor.Parent().(*ast.KeyValueExpr)
+	if !ok {
+		return false
+	}
+	return kv.Key == cursor.Node()
+}
+
+// isByteConversion 检查当前节点是否是 []byte 类型转换的参数，如 []byte("中文")
+func isByteConversion(cursor *astutil.Cursor) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 || call.Args[0] != cursor.Node() {
+		return false
+	}
+	array, ok := call.Fun.(*ast.ArrayType)
+	if !ok || array.Len != nil {
+		return false
+	}
+	elt, ok := array.Elt.(*ast.Ident)
+	return ok && elt.Name == "byte"
+}
+
+// wrapParentKinds -wrap-parents 支持的父节点类型
+var wrapParentKinds = map[string]bool{
+	"AssignStmt":   true,
+	"BinaryExpr":   true,
+	"CallExpr":     true,
+	"CaseClause":   true,
+	"CompositeLit": true,
+	"KeyValueExpr": true,
+	"ReturnStmt":   true,
+	"SendStmt":     true,
+	"ValueSpec":    true,
+}
+
+// validateWrapParents 检查 -wrap-parents 中的父节点类型是否受支持
+func validateWrapParents(kinds []string) error {
+	for _, kind := range kinds {
+		if !wrapParentKinds[kind] {
+			return fmt.Errorf("不支持的父节点类型: %s", kind)
+		}
+	}
+	return nil
+}
+
+// isAllowedParent 检查当前节点的父节点类型是否在 kinds 中，kinds 使用 go/ast 的类型名，如 AssignStmt
+func isAllowedParent(cursor *astutil.Cursor, kinds []string) bool {
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", cursor.Parent()), "*ast.")
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// isSinkArgument 检查当前节点是否直接作为 sinks 中某个函数的参数
+func isSinkArgument(cursor *astutil.Cursor, sinks []string) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok || call.Fun == cursor.Node() {
+		return false
+	}
+	name := calleeName(call)
+	for _, sink := range sinks {
+		if name == sink {
+			return true
+		}
+	}
+	return false
+}
+
+// isWrappedArgument 检查当前节点作为 positions 中函数的参数时是否位于指定下标
+// 不是这些函数的参数时不受限制
+func isWrappedArgument(cursor *astutil.Cursor, positions map[string]int) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok {
+		return true
+	}
+	index, ok := positions[calleeName(call)]
+	if !ok {
+		return true
+	}
+	return argIndex(call, cursor.Node()) == index
+}
+
+// calleeName 返回调用表达式中被调用函数的写法，如 fmt.Fprintf、c.JSON；无法表示时返回空
+func calleeName(call *ast.CallExpr) string {
+	return exprName(call.Fun)
+}
+
+// exprName 将由标识符和选择器组成的表达式还原为点分形式的名字
+func exprName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		x := exprName(e.X)
+		if x == "" {
+			return ""
+		}
+		return x + "." + e.Sel.Name
+	}
+	return ""
+}
+
+// splitList 解析逗号分隔的参数列表，忽略空白项
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// isWrappedByI18nT 检查字符串是否已是生成的调用中的原文，即 Message 的 field 字段
+func isWrappedByI18nT(cursor *astutil.Cursor, field string) bool {
+	// 检查父节点是否是 KeyValueExpr，且 Key 是 field
 	parent := cursor.Parent()
 	kv, ok := parent.(*ast.KeyValueExpr)
 	if !ok {
@@ -205,42 +1540,194 @@ func isWrappedByI18nT(cursor *astutil.Cursor) bool {
 	}
 	
 	key, ok := kv.Key.(*ast.Ident)
-	if !ok || key.Name != "Other" {
+	if !ok || key.Name != field {
 		return false
 	}
 	
-	// 简化处理：如果是 Other 字段，假设它在 i18n.Message 中
+	// 简化处理：如果是 field 字段，假设它在 i18n.Message 中
 	return true
 }
 
-func ensureI18nImport(file *ast.File, fset *token.FileSet) {
-	const importPath = "github.com/nicksnyder/go-i18n/v2/i18n"
+// i18nImportPath go-i18n 的导入路径
+const i18nImportPath = "github.com/nicksnyder/go-i18n/v2/i18n"
+
+// i18nAlias 文件中已有其他名为 i18n 的包时，导入 go-i18n 使用的名称
+const i18nAlias = "goi18n"
 
+// ensureI18nImport 按 i18nQualifier 返回的包名导入 go-i18n，已有可以按该名称使用的导入时不处理
+// 空白导入不能引用包中的标识符，此时另外添加导入
+func ensureI18nImport(file *ast.File, fset *token.FileSet, qualifier string) {
+	name := qualifier
+	if name == "" {
+		name = "."
+	}
 	for _, imp := range file.Imports {
-		if imp.Path.Value == `"`+importPath+`"` {
+		if imp.Path.Value == `"`+i18nImportPath+`"` && importName(imp) == name {
 			return
 		}
 	}
 
 	// 添加 go-i18n 导入
-	astutil.AddImport(fset, file, importPath)
+	if qualifier == i18nAlias {
+		astutil.AddNamedImport(fset, file, i18nAlias, i18nImportPath)
+		return
+	}
+	astutil.AddImport(fset, file, i18nImportPath)
 }
 
-// isInComment 检查给定的节点是否位于注释中
-func isInComment(node ast.Node, file *ast.File, fset *token.FileSet) bool {
-	// 获取节点的位置信息
-	nodePos := fset.Position(node.Pos())
-	nodeEnd := fset.Position(node.End())
+// i18nQualifier 返回文件中引用 go-i18n 时使用的包名
+// 直接导入时为 i18n，重命名导入时为导入的名称，点导入时为空，即生成的调用不带包名；
+// 未导入或只有空白导入时为 i18n，已有其他名为 i18n 的包时为 goi18n，避免与其冲突
+func i18nQualifier(file *ast.File) string {
+	conflict := false
+	for _, imp := range file.Imports {
+		if imp.Path.Value != `"`+i18nImportPath+`"` {
+			conflict = conflict || importName(imp) == "i18n"
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			return "i18n"
+		case imp.Name.Name == ".":
+			return ""
+		case imp.Name.Name != "_":
+			return imp.Name.Name
+		}
+	}
+	if conflict {
+		return i18nAlias
+	}
+	return "i18n"
+}
+
+// isI18nType 检查类型表达式是否为 go-i18n 中名为 name 的类型，qualifier 为 i18nQualifier 返回的包名
+func isI18nType(expr ast.Expr, qualifier, name string) bool {
+	if qualifier == "" {
+		ident, ok := expr.(*ast.Ident)
+		return ok && ident.Name == name
+	}
+	return isSelector(expr, qualifier, name)
+}
+
+// importName 返回导入在文件中使用的名称，未重命名时按惯例取路径的最后一段，忽略 /v2 等版本后缀
+func importName(imp *ast.ImportSpec) string {
+	if imp.Name != nil {
+		return imp.Name.Name
+	}
+	path, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(path, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && majorVersion.MatchString(name) {
+		name = parts[len(parts)-2]
+	}
+	return name
+}
+
+// majorVersion 匹配导入路径末尾的主版本号，如 v2
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
 
-	// 检查所有注释
+// qualifyI18n 将生成的调用中的 i18n.X 改为 qualifier.X，qualifier 为空时改为 X
+// TemplateData 中是原代码中的参数，保持不变
+func qualifyI18n(call *ast.CallExpr, qualifier string) *ast.CallExpr {
+	if qualifier == "i18n" {
+		return call
+	}
+	astutil.Apply(call, func(cursor *astutil.Cursor) bool {
+		switch n := cursor.Node().(type) {
+		case *ast.KeyValueExpr:
+			if key, ok := n.Key.(*ast.Ident); ok && key.Name == "TemplateData" {
+				return false
+			}
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok && x.Name == "i18n" {
+				if qualifier == "" {
+					cursor.Replace(ast.NewIdent(n.Sel.Name))
+				} else {
+					n.X = ast.NewIdent(qualifier)
+				}
+			}
+		}
+		return true
+	}, nil)
+	return call
+}
+
+// lineAnnotations 按行号记录注释中指定的值，如 //i18n:id= 指定的消息ID
+type lineAnnotations map[int]string
+
+// collectIDAnnotations 收集文件中所有 //i18n:id= 注释
+func collectIDAnnotations(file *ast.File, fset *token.FileSet) lineAnnotations {
+	return collectLineAnnotations(file, fset, idAnnotation)
+}
+
+// collectLineAnnotations 收集文件中所有匹配 re 的注释，记录第一个分组的内容
+func collectLineAnnotations(file *ast.File, fset *token.FileSet, re *regexp.Regexp) lineAnnotations {
+	code := codeLines(file, fset)
+	annotations := lineAnnotations{}
 	for _, commentGroup := range file.Comments {
 		for _, comment := range commentGroup.List {
-			commentPos := fset.Position(comment.Pos())
-			commentEnd := fset.Position(comment.End())
+			m := re.FindStringSubmatch(comment.Text)
+			if m == nil {
+				continue
+			}
+			// 行尾注释作用于同一行，单独一行的注释作用于下一行，两者同时存在时以行尾注释为准
+			line := fset.Position(comment.Pos()).Line
+			if code[line] {
+				annotations[line] = m[1]
+			} else if _, ok := annotations[line+1]; !ok {
+				annotations[line+1] = m[1]
+			}
+		}
+	}
+	return annotations
+}
+
+// codeLines 返回文件中有代码的行，节点的起止位置所在的行都视为有代码
+func codeLines(file *ast.File, fset *token.FileSet) map[int]bool {
+	lines := map[int]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, ok := n.(*ast.CommentGroup); ok {
+			return false
+		}
+		lines[fset.Position(n.Pos()).Line] = true
+		lines[fset.Position(n.End()).Line] = true
+		return true
+	})
+	return lines
+}
 
-			// 如果节点位置在注释范围内，则返回true
-			if (nodePos.Line > commentPos.Line || (nodePos.Line == commentPos.Line && nodePos.Column >= commentPos.Column)) &&
-				(nodeEnd.Line < commentEnd.Line || (nodeEnd.Line == commentEnd.Line && nodeEnd.Column <= commentEnd.Column)) {
+// take 取出作用于 line 行字符串的注释，注释可以位于同一行或单独位于紧邻的上一行
+// 每条注释只作用于一个字符串，取出后即被移除
+func (a lineAnnotations) take(line int) (string, bool) {
+	id, ok := a[line]
+	delete(a, line)
+	return id, ok
+}
+
+// validateMessageID 检查消息ID是否符合 go-i18n 的要求
+func validateMessageID(id string) error {
+	if !validMessageID.MatchString(id) {
+		return fmt.Errorf("消息ID %q 不合法，需以字母开头且只包含字母、数字、_、.、-", id)
+	}
+	return nil
+}
+
+// isInComment 检查给定的节点是否位于注释中
+// 直接比较文件内的偏移位置，同一行中代码后的注释不会影响代码中的字符串
+func isInComment(node ast.Node, file *ast.File) bool {
+	for _, commentGroup := range file.Comments {
+		// 注释组与节点没有交集时跳过整个注释组
+		if commentGroup.End() <= node.Pos() || commentGroup.Pos() >= node.End() {
+			continue
+		}
+		for _, comment := range commentGroup.List {
+			if node.Pos() >= comment.Pos() && node.End() <= comment.End() {
 				return true
 			}
 		}
@@ -265,38 +1752,98 @@ func isInComment(node ast.Node, file *ast.File, fset *token.FileSet) bool {
 // }
 
 // generateMessageID 根据中文消息生成唯一ID
-func generateMessageID(message string) string {
+func generateMessageID(message string, opts Options) string {
 	// 去除引号
 	message = strings.Trim(message, `"`)
 
+	// HTML 片段只根据标签外的文本生成ID
+	if opts.HTMLAware {
+		message = stripHTMLTags(message)
+	}
+	if opts.Traditional2Simplified {
+		message = toSimplified(message)
+	}
+
+	// 哈希ID由完整原文决定，首尾空白不同的原文已经得到不同的ID
+	if opts.IDScheme == idSchemeHash {
+		return hashMessageID(message)
+	}
+
 	// 提取前几个字符作为前缀，转为拼音
-	prefix := extractPinyinPrefix(message, 5)
-	// 组合前缀和哈希
-	return prefix
+	words := extractPinyinWords(message, opts.idLength(), opts.PinyinStyle)
+	// 按配置的大小写风格组合各个单词，未指定时使用分隔符连接
+	id := strings.Join(words, opts.IDSeparator)
+	if opts.IDCase != "" {
+		id = applyIDCase(words, opts.IDCase)
+	}
+	if opts.WhitespaceSensitiveIDs && hasSurroundingSpace(message) {
+		id += "_" + whitespaceSuffix
+	}
+	return id
+}
+
+// hashMessageID 返回 -id-scheme hash 时的消息ID：msg_ 加原文的哈希，与不合法的拼音ID改用的ID相同
+// message 是去掉双引号后的字面量内容，先解码转义，同一原文的不同写法得到相同的ID
+func hashMessageID(message string) string {
+	if unquoted, err := strconv.Unquote(`"` + message + `"`); err == nil {
+		message = unquoted
+	}
+	return "msg_" + hashSuffix(message)
+}
+
+// whitespaceSuffix 开启 -whitespace-sensitive-ids 时首尾带空白的字符串的ID后缀
+const whitespaceSuffix = "ws"
+
+// hasSurroundingSpace 检查字符串首尾是否有空白，message 可以是去掉双引号后的字面量内容
+func hasSurroundingSpace(message string) bool {
+	if unquoted, err := strconv.Unquote(`"` + message + `"`); err == nil {
+		message = unquoted
+	}
+	return message != strings.TrimSpace(message)
+}
+
+// stripHTMLTags 去除字符串中的 HTML 标签（包括标签属性），标签替换为空格以保留单词边界
+func stripHTMLTags(message string) string {
+	return htmlTag.ReplaceAllString(message, " ")
 }
 
-// extractPinyinPrefix 从中文消息中提取拼音首字母作为前缀
-func extractPinyinPrefix(message string, maxChars int) string {
+// firstLetterArgs 取拼音首字母的参数，只创建一次
+// pinyin.Args 按值传递，转换时只读取拼音字典，可以在多个 goroutine 中同时使用
+var firstLetterArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Style = pinyin.FirstLetter
+	return args
+}()
+
+// fullPinyinArgs 取不带声调的完整拼音的参数，与 firstLetterArgs 一样只创建一次
+var fullPinyinArgs = pinyin.NewArgs()
+
+// extractPinyinWords 从消息中提取组成ID的单词
+// 中文按字切分，每个字按 style 指定的拼音风格转换为一个单词；不含中文时按连续的字母数字切分
+// 结果不以字母开头时返回 ["msg"]
+func extractPinyinWords(message string, maxChars int, style string) []string {
+	fallback := []string{"msg"}
 	if len(message) == 0 {
-		return "msg"
+		return fallback
 	}
 
 	// 去除引号
 	message = strings.Trim(message, `"`)
 	
+	var words []string
 	// 检查是否包含中文字符
 	if hasChinese.MatchString(message) {
 		// 如果包含中文，只提取中文字符的拼音
-		var result strings.Builder
 		count := 0
+		args := firstLetterArgs
+		if style == pinyinStyleFull {
+			args = fullPinyinArgs
+		}
 		
-		for _, char := range []rune(message) {
-			if hasChinese.MatchString(string(char)) {
-				args := pinyin.NewArgs()
-				args.Style = pinyin.FirstLetter
-				pys := pinyin.Pinyin(string(char), args)
-				if len(pys) > 0 && len(pys[0]) > 0 {
-					result.WriteString(pys[0][0])
+		for _, char := range message {
+			if unicode.Is(unicode.Han, char) {
+				if py := pinyin.SinglePinyin(char, args); len(py) > 0 {
+					words = append(words, py[0])
 					count++
 					if count >= maxChars {
 						break
@@ -304,33 +1851,121 @@ func extractPinyinPrefix(message string, maxChars int) string {
 				}
 			}
 		}
-		
-		id := result.String()
-		if id != "" && regexp.MustCompile(`^[a-zA-Z]`).MatchString(id) {
-			return id
-		}
-		return "msg"
 	} else {
-		// 如果不包含中文，处理英文和数字
-		var result strings.Builder
+		// 如果不包含中文，处理英文和数字，连续的字母数字组成一个单词
+		var word strings.Builder
 		count := 0
 		
-		for _, char := range []rune(message) {
-			if regexp.MustCompile(`[a-zA-Z0-9]`).MatchString(string(char)) {
-				result.WriteString(strings.ToLower(string(char)))
+		for _, char := range message {
+			if isASCIIAlnum(char) {
+				word.WriteRune(unicode.ToLower(char))
 				count++
 				if count >= maxChars {
 					break
 				}
+			} else if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
 			}
 		}
-		
-		id := result.String()
-		if id != "" && regexp.MustCompile(`^[a-zA-Z]`).MatchString(id) {
-			return id
+		if word.Len() > 0 {
+			words = append(words, word.String())
 		}
-		return "msg"
 	}
+
+	id := strings.Join(words, "")
+	if id != "" && isASCIILetter(rune(id[0])) {
+		return words
+	}
+	return fallback
+}
+
+// isASCIILetter 检查字符是否为 ASCII 字母
+func isASCIILetter(r rune) bool {
+	return r < utf8.RuneSelf && unicode.IsLetter(r)
+}
+
+// isASCIIAlnum 检查字符是否为 ASCII 字母或数字
+func isASCIIAlnum(r rune) bool {
+	return r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// validateIDCase 检查消息ID大小写风格是否受支持
+func validateIDCase(style string) error {
+	switch style {
+	case "", idCaseSnake, idCaseCamel, idCasePascal:
+		return nil
+	}
+	return fmt.Errorf("不支持的ID大小写风格: %s", style)
+}
+
+// validateIDScheme 检查ID生成方式是否受支持
+func validateIDScheme(scheme string) error {
+	switch scheme {
+	case "", idSchemePinyin, idSchemeHash:
+		return nil
+	}
+	return fmt.Errorf("不支持的ID生成方式: %s，可选 %s、%s", scheme, idSchemePinyin, idSchemeHash)
+}
+
+// validatePinyinStyle 检查拼音风格是否受支持
+func validatePinyinStyle(style string) error {
+	switch style {
+	case "", pinyinStyleFirstLetter, pinyinStyleFull:
+		return nil
+	}
+	return fmt.Errorf("不支持的拼音风格: %s，可选 %s、%s", style, pinyinStyleFirstLetter, pinyinStyleFull)
+}
+
+// defaultIDSeparator 返回拼音风格默认的分隔符，完整拼音之间用 _ 分隔以便阅读
+func defaultIDSeparator(style string) string {
+	if style == pinyinStyleFull {
+		return "_"
+	}
+	return ""
+}
+
+// validateIDSeparator 检查分隔符连接后的ID是否仍符合 go-i18n 的要求
+func validateIDSeparator(sep string) error {
+	if err := validateMessageID("a" + sep + "b"); err != nil {
+		return fmt.Errorf("分隔符 %q 不合法，只能包含字母、数字、_、.、-", sep)
+	}
+	return nil
+}
+
+// applyIDCase 按指定风格将单词组合为消息ID
+// 首个单词以字母开头，因此各风格的结果同样以字母开头
+func applyIDCase(words []string, style string) string {
+	switch style {
+	case idCaseSnake:
+		return strings.Join(words, "_")
+	case idCaseCamel:
+		var b strings.Builder
+		for i, w := range words {
+			if i == 0 {
+				b.WriteString(w)
+			} else {
+				b.WriteString(capitalize(w))
+			}
+		}
+		return b.String()
+	case idCasePascal:
+		var b strings.Builder
+		for _, w := range words {
+			b.WriteString(capitalize(w))
+		}
+		return b.String()
+	default:
+		return strings.Join(words, "")
+	}
+}
+
+// capitalize 将单词首字母转为大写
+func capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
 }
 
 // containsChinese 检查字符串是否包含中文字符
@@ -346,19 +1981,48 @@ func containsChinese(s string) bool {
 	return false
 }
 
-// isInStructTagBasicLit 检查给定的 BasicLit 是否位于结构体标签中
-func isInStructTagBasicLit(lit *ast.BasicLit, file *ast.File) bool {
-	// 遍历所有结构体字段
-	var result bool
-	ast.Inspect(file, func(n ast.Node) bool {
-		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
-			// 检查标签是否就是当前的字符串字面量
-			if field.Tag == lit {
-				result = true
-				return false
+// placeAt 把生成的调用中没有位置的节点放到被替换节点所在的位置
+// 没有位置的节点会让打印器把附近的注释插入到调用内部，右括号放在 end 处，使行尾注释仍留在调用之后
+func placeAt(call *ast.CallExpr, pos, end token.Pos) *ast.CallExpr {
+	ast.Inspect(call, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if !n.NamePos.IsValid() {
+				n.NamePos = pos
+			}
+		case *ast.BasicLit:
+			if !n.ValuePos.IsValid() {
+				n.ValuePos = pos
+			}
+		case *ast.CallExpr:
+			if !n.Lparen.IsValid() {
+				n.Lparen = pos
+			}
+			if !n.Rparen.IsValid() {
+				n.Rparen = pos
+			}
+		case *ast.CompositeLit:
+			if !n.Lbrace.IsValid() {
+				n.Lbrace = pos
+			}
+			if !n.Rbrace.IsValid() {
+				n.Rbrace = pos
+			}
+		case *ast.UnaryExpr:
+			if !n.OpPos.IsValid() {
+				n.OpPos = pos
+			}
+		case *ast.KeyValueExpr:
+			if !n.Colon.IsValid() {
+				n.Colon = pos
+			}
+		case *ast.MapType:
+			if !n.Map.IsValid() {
+				n.Map = pos
 			}
 		}
 		return true
 	})
-	return result
+	call.Rparen = end - 1
+	return call
 }
@@ -2,17 +2,22 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
 	"io" // 添加这一行导入 io 包
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/tools/go/ast/astutil"
 )
 
 // 测试命令行参数处理
@@ -45,8 +50,10 @@ func main() {
 	// 设置命令行参数
 	os.Args = []string{"cmd", inputFile, outputFile}
 
-	// 执行 main 函数
-	main()
+	// 执行转换，输出文件与输入不同
+	if code := run(os.Args[1:]); code != exitChanges {
+		t.Errorf("退出码 = %d，期望 %d", code, exitChanges)
+	}
 
 	// 验证输出文件是否存在
 	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
@@ -66,6 +73,124 @@ func main() {
 	}
 }
 
+func TestMainWithMultipleFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	a := writeTestFile(t, tempDir, "a.go", `package main
+
+var greeting = "你好世界"
+`)
+	b := writeTestFile(t, tempDir, "b.go", `package main
+
+var notice = "你好时间"
+`)
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+
+	assert.Equal(t, exitChanges, run([]string{"-w", "-bundle-out", bundlePath, a, b}))
+
+	// 两个文件都被原地改写，不同文件中的原文也不会得到相同的ID
+	data, err := os.ReadFile(a)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "nhsj"`)
+	data, err = os.ReadFile(b)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "nhsj_`+hashSuffix("你好时间")+`"`)
+
+	bundle, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Len(t, bundle, 2)
+
+	// 不带 -w 时只接受输入和输出两个参数
+	assert.Equal(t, exitUsage, run([]string{a, b, filepath.Join(tempDir, "c.go")}))
+}
+
+func TestMainWithMultipleFilesParseError(t *testing.T) {
+	tempDir := t.TempDir()
+	content := `package main
+
+var greeting = "你好世界"
+`
+	good := writeTestFile(t, tempDir, "good.go", content)
+	bad := writeTestFile(t, tempDir, "bad.go", "package main\n\nvar = \n")
+
+	// 任何一个文件解析失败时不修改其他文件
+	assert.Equal(t, exitError, run([]string{"-w", good, bad}))
+	data, err := os.ReadFile(good)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
+func TestRefuseOverwrite(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	existing := "// 其他人的文件\npackage main\n"
+	output := writeTestFile(t, tempDir, "output.go", existing)
+
+	// 输出文件已存在且内容不同，拒绝覆盖
+	assert.Equal(t, exitError, run([]string{input, output}))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, existing, string(data))
+
+	assert.Equal(t, exitChanges, run([]string{"-force", input, output}))
+	data, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "nhsj"`)
+
+	// 内容相同时可以重复运行
+	assert.Equal(t, exitChanges, run([]string{input, output}))
+}
+
+func TestGoImports(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+import "fmt"
+
+func example() {
+	fmt.Println("你好世界")
+}
+`)
+	output := filepath.Join(tempDir, "output.go")
+
+	// 新增的导入与标准库分为两组
+	assert.Equal(t, exitChanges, run([]string{"-goimports", input, output}))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "import (\n\t\"fmt\"\n\n\t\""+i18nImportPath+"\"\n)")
+
+	// 不带 -goimports 时导入不分组
+	plain := filepath.Join(tempDir, "plain.go")
+	assert.Equal(t, exitChanges, run([]string{input, plain}))
+	data, err = os.ReadFile(plain)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "import (\n\t\"fmt\"\n\t\""+i18nImportPath+"\"\n)")
+}
+
+func TestGoImportsSkipsUnchangedFile(t *testing.T) {
+	// 导入顺序不符合 goimports 的规则，但文件没有需要替换的字符串，保持原样
+	content := `package main
+
+import (
+	"strings"
+	"fmt"
+)
+
+func example() {
+	fmt.Println(strings.ToUpper("hello"))
+}
+`
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", content)
+
+	assert.Equal(t, 0, run([]string{"-goimports", "-w", input}))
+	data, err := os.ReadFile(input)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
+
 func TestTransform(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -93,86 +218,1712 @@ func example() {
 			name: "ignore English string",
 			input: `package main
 
-func example() {
-	s := "Hello World"
-}`,
-			expected: `package main
+func example() {
+	s := "Hello World"
+}`,
+			expected: `package main
+
+func example() {
+	s := "Hello World"
+}`,
+		},
+		{
+			name: "ignore struct tags",
+			input: `package main
+
+type Person struct {
+	Name string ` + "`json:\"姓名\"`" + `
+}`,
+			expected: `package main
+
+type Person struct {
+	Name string ` + "`json:\"姓名\"`" + `
+}`,
+		},
+		{
+			name: "ignore wrapped string",
+			input: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example() {
+	s := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
+}`,
+			expected: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example() {
+	s := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
+}`,
+		},
+		{
+			name: "ignore Chinese in comments",
+			input: `package main
+
+// 这是一个中文注释
+func example() {
+	// 另一个中文注释
+	s := "Hello"
+	/* 这也是中文注释 */
+}`,
+			expected: `package main
+
+// 这是一个中文注释
+func example() {
+	// 另一个中文注释
+	s := "Hello"
+	/* 这也是中文注释 */
+}`,
+		},
+		{
+			name: "transform values of map with non-string keys",
+			input: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+type Status int
+
+const Active Status = 1
+
+var names = map[Status]string{Active: "启用中"}`,
+			expected: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+type Status int
+
+const Active Status = 1
+
+var names = map[Status]string{Active: i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "qyz", DefaultMessage: &i18n.Message{ID: "qyz", Other: "启用中"}})}`,
+		},
+		{
+			name: "transform channel send",
+			input: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(ch chan string) {
+	ch <- "中文消息"
+}`,
+			expected: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(ch chan string) {
+	ch <- i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "zwxx", DefaultMessage: &i18n.Message{ID: "zwxx", Other: "中文消息"}})
+}`,
+		},
+		{
+			name: "transform send in select case",
+			input: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(ch chan string, done chan struct{}) {
+	select {
+	case ch <- "中文消息":
+	case <-done:
+	}
+}`,
+			expected: `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(ch chan string, done chan struct{}) {
+	select {
+	case ch <- i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "zwxx", DefaultMessage: &i18n.Message{ID: "zwxx", Other: "中文消息"}}):
+	case <-done:
+	}
+}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _, err := TransformSource([]byte(tt.input), Options{})
+			assert.NoError(t, err)
+
+			// 规范化字符串（移除多余的空白字符）
+			normalizedResult := strings.TrimSpace(string(output))
+			normalizedExpected := strings.TrimSpace(tt.expected)
+
+			assert.Equal(t, normalizedExpected, normalizedResult)
+		})
+	}
+}
+
+func TestTransformSource(t *testing.T) {
+	src := []byte(`package main
+
+func example() {
+	s := "你好世界"
+}
+`)
+
+	output, msgs, err := TransformSource(src, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{{ID: "nhsj", Other: "你好世界", Position: token.Position{Offset: 37, Line: 4, Column: 7}}}, msgs)
+	assert.Contains(t, string(output), "import \"github.com/nicksnyder/go-i18n/v2/i18n\"\n")
+	assert.Contains(t, string(output), "\n\ts := i18n.Localizer.MustLocalize(")
+
+	// 与命令行输出的缩进一致
+	output, _, err = TransformSource(src, Options{UseSpaces: true, TabWidth: 4})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "\n    s := i18n.Localizer.MustLocalize(")
+
+	_, _, err = TransformSource([]byte("package main\n\nvar = \n"), Options{})
+	assert.Error(t, err)
+
+	_, _, err = TransformSource([]byte(`package main
+
+var a, b = "你好时间", "你好世界"
+`), Options{UniqueSuffix: uniqueSuffixNone})
+	var collision *CollisionError
+	assert.ErrorAs(t, err, &collision)
+}
+
+func TestTransformTo(t *testing.T) {
+	src := `package main
+
+func example() {
+	s := "你好世界"
+}
+`
+	fset, file := parseSource(t, src)
+	var buf bytes.Buffer
+	assert.NoError(t, TransformTo(&buf, file, fset, Options{}))
+	expected, _, err := TransformSource([]byte(src), Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buf.String())
+
+	// 转换失败时不写入
+	fset, file = parseSource(t, `package main
+
+var a, b = "你好时间", "你好世界"
+`)
+	buf.Reset()
+	var collision *CollisionError
+	assert.ErrorAs(t, TransformTo(&buf, file, fset, Options{UniqueSuffix: uniqueSuffixNone}), &collision)
+	assert.Zero(t, buf.Len())
+
+	// 写入失败时返回错误
+	fset, file = parseSource(t, src)
+	assert.Error(t, TransformTo(failingWriter{}, file, fset, Options{}))
+}
+
+// failingWriter 总是写入失败的 io.Writer
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrShortWrite
+}
+
+// parseSource 解析测试用的源码
+func parseSource(t *testing.T, src string) (*token.FileSet, *ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("解析源码失败: %v", err)
+	}
+	return fset, file
+}
+
+// transformString 解析并转换源码，返回转换后的代码和被替换的消息
+func transformString(t *testing.T, src string, opts Options) (string, []Message) {
+	t.Helper()
+	output, msgs, err := TransformSource([]byte(src), opts)
+	if err != nil {
+		t.Fatalf("转换源码失败: %v", err)
+	}
+	return string(output), stripPositions(msgs)
+}
+
+// stripPositions 去掉消息中的源码位置，便于只比较ID和原文
+func stripPositions(msgs []Message) []Message {
+	for i := range msgs {
+		msgs[i].Position = token.Position{}
+	}
+	return msgs
+}
+
+func TestIDAnnotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []Message
+	}{
+		{
+			name: "annotation on the same line",
+			input: `package main
+
+func example() {
+	s := "登录标题" //i18n:id=login_title
+}`,
+			expected: []Message{{ID: "login_title", Other: "登录标题"}},
+		},
+		{
+			name: "annotation on the preceding line",
+			input: `package main
+
+func example() {
+	//i18n:id=login_title
+	s := "登录标题"
+	t := "你好世界"
+}`,
+			expected: []Message{
+				{ID: "login_title", Other: "登录标题"},
+				{ID: "nhsj", Other: "你好世界"},
+			},
+		},
+		{
+			name: "invalid annotation falls back to generated id",
+			input: `package main
+
+func example() {
+	s := "登录标题" //i18n:id=1登录
+}`,
+			expected: []Message{{ID: "dlbt", Other: "登录标题"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, msgs := transformString(t, tt.input, Options{})
+			assert.Equal(t, tt.expected, msgs)
+		})
+	}
+}
+
+func TestIDAnnotationOverridesGeneratedID(t *testing.T) {
+	output, _ := transformString(t, `package main
+
+func example() {
+	s := "登录标题" //i18n:id=login_title
+}`, Options{})
+
+	assert.NotEqual(t, "login_title", generateMessageID(`"登录标题"`, Options{}))
+	assert.Contains(t, output, `MessageID: "login_title"`)
+	assert.Contains(t, output, `ID: "login_title"`)
+	assert.NotContains(t, output, `"dlbt"`)
+}
+
+// Error 方法中返回的中文同样被替换；Localizer 是包级变量，值接收者和指针接收者的方法都能访问
+func TestErrorMethod(t *testing.T) {
+	output, msgs := transformString(t, `package main
+
+type NotFoundError struct{}
+
+func (e NotFoundError) Error() string { return "记录不存在" }
+
+type TimeoutError struct{}
+
+func (e *TimeoutError) Error() string {
+	return "请求超时"
+}`, Options{})
+
+	assert.Equal(t, []Message{
+		{ID: "jlbcz", Other: "记录不存在"},
+		{ID: "qqcs", Other: "请求超时"},
+	}, msgs)
+	assert.Contains(t, output, `func (e NotFoundError) Error() string {
+	return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "jlbcz"`)
+	assert.Contains(t, output, `	return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "qqcs"`)
+	assert.Contains(t, output, i18nImportPath)
+
+	// 输出仍是合法的 Go 代码
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+}
+
+func TestValidateMessageID(t *testing.T) {
+	for _, id := range []string{"login_title", "auth.login", "a-b", "A1"} {
+		assert.NoError(t, validateMessageID(id), id)
+	}
+	for _, id := range []string{"", "1abc", "登录", "a b", "_a"} {
+		assert.Error(t, validateMessageID(id), id)
+	}
+}
+
+func TestFoldStringConcat(t *testing.T) {
+	output, msgs := transformString(t, `package main
+
+func example() {
+	s := "第一行" +
+		"第二行"
+	t := "前缀" + name + "后缀"
+}`, Options{})
+
+	assert.Equal(t, []Message{
+		{ID: "dyxde", Other: "第一行第二行"},
+		{ID: "qz", Other: "前缀"},
+		{ID: "hz", Other: "后缀"},
+	}, msgs)
+	assert.Contains(t, output, `Other: "第一行第二行"`)
+	assert.NotContains(t, output, `+
+		"第二行"`)
+	assert.Contains(t, output, `+ name +`)
+}
+
+func TestIndexKeys(t *testing.T) {
+	input := `package main
+
+func example(m map[string]string) {
+	s := m["中文键"]
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Empty(t, msgs)
+	assert.Contains(t, output, `m["中文键"]`)
+
+	output, msgs = transformString(t, input, Options{WrapIndexKeys: true})
+	assert.Equal(t, []Message{{ID: "zwj", Other: "中文键"}}, msgs)
+	assert.NotContains(t, output, `m["中文键"]`)
+}
+
+func TestMapLiteralChineseKeysAndValues(t *testing.T) {
+	input := `package main
+
+var labels = map[string]string{
+	"键中文": "值中文",
+	"相同":  "相同",
+}
+
+var nested = map[string]map[string]string{
+	"外层": {"内层": "内层值"},
+}`
+
+	// 默认只替换值，键保持不变，即使值与键的原文相同
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "zzw", Other: "值中文"},
+		{ID: "xt", Other: "相同"},
+		{ID: "ncz", Other: "内层值"},
+	}, msgs)
+	assert.Regexp(t, `\n\t"键中文":\s+i18n\.Localizer\.MustLocalize\(`, output)
+	assert.Regexp(t, `\n\t"相同":\s+i18n\.Localizer\.MustLocalize\(`, output)
+	assert.Contains(t, output, `"外层": {"内层": i18n.Localizer.MustLocalize(`)
+	typeCheck(t, output)
+
+	// -wrap-index-keys 时键和值都替换，相同的原文使用同一个ID
+	output, msgs = transformString(t, input, Options{WrapIndexKeys: true})
+	assert.Equal(t, []Message{
+		{ID: "jzw", Other: "键中文"},
+		{ID: "zzw", Other: "值中文"},
+		{ID: "xt", Other: "相同"},
+		{ID: "xt", Other: "相同"},
+		{ID: "wc", Other: "外层"},
+		{ID: "nc", Other: "内层"},
+		{ID: "ncz", Other: "内层值"},
+	}, msgs)
+	assert.NotRegexp(t, `"(键中文|相同|外层|内层)":`, output)
+	typeCheck(t, output)
+}
+
+// i18nStub 类型检查时代替 go-i18n 的最小实现，只包含生成的代码用到的部分
+const i18nStub = `package i18n
+
+type Message struct {
+	ID          string
+	Description string
+	Other       string
+}
+
+type LocalizeConfig struct {
+	MessageID      string
+	DefaultMessage *Message
+	TemplateData   any
+}
+
+type localizer struct{}
+
+func (localizer) MustLocalize(*LocalizeConfig) string { return "" }
+
+func (localizer) Localize(*LocalizeConfig) (string, error) { return "", nil }
+
+var Localizer localizer
+`
+
+// stubImporter 只能导入 go-i18n 的桩实现和 pkgs 中按导入路径给出源码的包
+type stubImporter struct {
+	fset *token.FileSet
+	pkgs map[string]string
+}
+
+func (im stubImporter) Import(path string) (*types.Package, error) {
+	src, ok := im.pkgs[path]
+	if path == i18nImportPath {
+		src, ok = i18nStub, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("测试中不支持导入 %s", path)
+	}
+	file, err := parser.ParseFile(im.fset, path+".go", src, 0)
+	if err != nil {
+		return nil, err
+	}
+	return (&types.Config{}).Check(path, im.fset, []*ast.File{file}, nil)
+}
+
+// typeCheck 检查转换后的源码能否通过类型检查，源码只能导入 go-i18n
+func typeCheck(t *testing.T, src string) {
+	t.Helper()
+	typeCheckWith(t, src, nil)
+}
+
+// typeCheckWith 与 typeCheck 相同，源码还可以导入 pkgs 中的包
+func typeCheckWith(t *testing.T, src string, pkgs map[string]string) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "output.go", src, 0)
+	if err != nil {
+		t.Fatalf("解析源码失败: %v", err)
+	}
+	config := types.Config{Importer: stubImporter{fset: fset, pkgs: pkgs}}
+	if _, err := config.Check("main", fset, []*ast.File{file}, nil); err != nil {
+		t.Errorf("类型检查失败: %v\n%s", err, src)
+	}
+}
+
+func TestVariadicBuiltins(t *testing.T) {
+	input := `package main
+
+func example(items []string, buf []byte) []string {
+	items = append(items, "第一项", "第二项")
+	items = append(items, []string{"切片元素"}...)
+	buf = append(buf, "字节内容"...)
+	copy(buf, "复制内容")
+	return items
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "dyx", Other: "第一项"},
+		{ID: "dex", Other: "第二项"},
+		{ID: "qpys", Other: "切片元素"},
+		{ID: "zjnr", Other: "字节内容"},
+		{ID: "fznr", Other: "复制内容"},
+	}, msgs)
+	typeCheck(t, output)
+}
+
+func TestNoPanic(t *testing.T) {
+	input := `package main
+
+var title = "标题"
+
+func show(string) {}
+
+func example() string {
+	var name string = "姓名"
+	msg := "成功"
+	msg = "失败"
+	show("参数")
+	_ = name
+	return msg
+}`
+
+	var log bytes.Buffer
+	output, msgs := transformString(t, input, Options{NoPanic: true, DiagnosticLog: &log})
+	assert.Len(t, msgs, 5)
+	assert.Contains(t, output, `var title, _ = i18n.Localizer.Localize(`)
+	assert.Contains(t, output, `msg, _ := i18n.Localizer.Localize(`)
+	assert.Contains(t, output, `msg, _ = i18n.Localizer.Localize(`)
+
+	// 带类型的声明和函数参数无法接收 error，仍使用 MustLocalize
+	assert.Contains(t, output, `var name string = i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `show(i18n.Localizer.MustLocalize(`)
+	assert.Equal(t, 2, strings.Count(log.String(), `"code":"no-panic"`))
+	typeCheck(t, output)
+
+	// 默认不改变
+	output, _ = transformString(t, input, Options{})
+	assert.NotContains(t, output, ".Localize(")
+}
+
+func TestStringArrays(t *testing.T) {
+	input := `package main
+
+type Level int
+
+const (
+	Low Level = iota
+	Mid
+	High
+)
+
+var levelNames = [...]string{"低", "中", "高"}
+
+var weekdays = [...]string{Low: "星期一", High: "星期三"}
+
+func (l Level) String() string { return levelNames[l] }
+
+func check() {
+	var _ [3]string = levelNames
+	var _ [3]string = weekdays
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "d", Other: "低"},
+		{ID: "z", Other: "中"},
+		{ID: "g", Other: "高"},
+		{ID: "xqy", Other: "星期一"},
+		{ID: "xqs", Other: "星期三"},
+	}, msgs)
+	// 数组保留 [...] 和下标键，长度仍由元素推断
+	assert.Contains(t, output, `levelNames = [...]string{i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `weekdays = [...]string{Low: i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `High: i18n.Localizer.MustLocalize(`)
+	typeCheck(t, output)
+}
+
+func TestDotImports(t *testing.T) {
+	// 其他包的点导入不影响生成的调用，仍需添加 go-i18n 导入
+	output, msgs := transformString(t, `package main
+
+import . "strings"
+
+func example() string {
+	return ToUpper("你好")
+}`, Options{})
+	assert.Equal(t, []Message{{ID: "nh", Other: "你好"}}, msgs)
+	assert.Contains(t, output, `. "strings"`)
+	assert.Contains(t, output, `"`+i18nImportPath+`"`)
+	assert.Contains(t, output, `ToUpper(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{`)
+
+	// 点导入 go-i18n 时生成不带包名的调用
+	output, _ = transformString(t, `package main
+
+import . "`+i18nImportPath+`"
+
+var _ = Localizer
+
+func example() string {
+	return "你好"
+}`, Options{})
+	assert.Equal(t, 1, strings.Count(output, i18nImportPath))
+	assert.Contains(t, output, `return Localizer.MustLocalize(&LocalizeConfig{MessageID: "nh", DefaultMessage: &Message{ID: "nh", Other: "你好"}})`)
+	assert.NotContains(t, output, "i18n.")
+	typeCheck(t, output)
+
+	// 重命名导入时使用导入的名称
+	output, _ = transformString(t, `package main
+
+import goi18n "`+i18nImportPath+`"
+
+var _ = goi18n.Localizer
+
+func example() string {
+	return "你好"
+}`, Options{})
+	assert.Contains(t, output, `return goi18n.Localizer.MustLocalize(&goi18n.LocalizeConfig{MessageID: "nh", DefaultMessage: &goi18n.Message{`)
+	typeCheck(t, output)
+
+	// 空白导入不能引用 go-i18n 中的标识符，另外添加导入
+	blank := `package main
+
+import _ "` + i18nImportPath + `"
+
+func example() string {
+	return "你好"
+}`
+	output, _ = transformString(t, blank, Options{})
+	assert.Contains(t, output, `_ "`+i18nImportPath+`"`)
+	assert.Equal(t, 2, strings.Count(output, i18nImportPath))
+	assert.Contains(t, output, `return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{`)
+	typeCheck(t, output)
+
+	// 还原时只删除添加的导入
+	fset, file := parseSource(t, output)
+	assert.Equal(t, []string{"nh"}, revert(file, fset, Options{}))
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.Contains(t, buf.String(), `_ "`+i18nImportPath+`"`)
+	assert.Equal(t, 1, strings.Count(buf.String(), i18nImportPath))
+	assert.Contains(t, buf.String(), `return "你好"`)
+}
+
+func TestCrossPackageCalls(t *testing.T) {
+	pkgs := map[string]string{
+		"example.com/app/notify": `package notify
+
+type Sender interface{ Send(msg string) }
+
+var Default Sender
+`,
+		"example.com/app/i18n": `package i18n
+
+func Tr(key string) string { return key }
+`,
+	}
+	input := `package main
+
+import (
+	"example.com/app/i18n"
+	"example.com/app/notify"
+)
+
+func example(s notify.Sender) {
+	s.Send("发送成功")
+	notify.Default.Send(i18n.Tr("标题"))
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "fscg", Other: "发送成功"},
+		{ID: "bt", Other: "标题"},
+	}, msgs)
+	// 已有同名的 i18n 包时以 goi18n 导入 go-i18n，不影响原有的调用
+	assert.Contains(t, output, `goi18n "`+i18nImportPath+`"`)
+	assert.Contains(t, output, `s.Send(goi18n.Localizer.MustLocalize(&goi18n.LocalizeConfig{MessageID: "fscg"`)
+	assert.Contains(t, output, `notify.Default.Send(i18n.Tr(goi18n.Localizer.MustLocalize(`)
+	typeCheckWith(t, output, pkgs)
+
+	// 没有冲突时照常使用 i18n
+	output, _ = transformString(t, `package main
+
+import "example.com/app/notify"
+
+func example() {
+	notify.Default.Send("发送成功")
+}`, Options{})
+	assert.Contains(t, output, `notify.Default.Send(i18n.Localizer.MustLocalize(`)
+	typeCheckWith(t, output, pkgs)
+
+	assert.Equal(t, "i18n", importName(&ast.ImportSpec{Path: &ast.BasicLit{Value: `"example.com/i18n/v3"`}}))
+	assert.Equal(t, "tr", importName(&ast.ImportSpec{Name: ast.NewIdent("tr"), Path: &ast.BasicLit{Value: `"example.com/i18n"`}}))
+}
+
+func TestAnonymousStructLiterals(t *testing.T) {
+	input := `package main
+
+var tip = struct{ Msg string }{Msg: "提示"}
+
+var rows = []struct {
+	Name  string
+	Label string ` + "`label:\"标签\"`" + `
+}{
+	{"名称", "说明"},
+	{Name: "键"},
+}
+
+func example() any {
+	return struct {
+		Msg string
+	}{"警告"}
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "ts", Other: "提示"},
+		{ID: "mc", Other: "名称"},
+		{ID: "sm", Other: "说明"},
+		{ID: "j", Other: "键"},
+		{ID: "jg", Other: "警告"},
+	}, msgs)
+	// 匿名结构体的类型、字段名和标签保持不变，只替换字段值
+	assert.Contains(t, output, `struct{ Msg string }{Msg: i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, "`label:\"标签\"`")
+	assert.Contains(t, output, `{Name: i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, "}{i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: \"jg\"")
+	typeCheck(t, output)
+}
+
+func TestSourceAsDescription(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(n int) (string, string) {
+	return "你好世界", fmt.Sprintf("第%d页", n)
+}`
+	opts := Options{SourceAsDescription: true, ConvertSprintf: true}
+
+	output, msgs := transformString(t, input, opts)
+	assert.Equal(t, []Message{
+		{ID: "nhsj", Other: "", Description: "你好世界"},
+		{ID: "dy", Other: "", Description: "第{{.Arg0}}页"},
+	}, msgs)
+	// 原文写入 Description，Other 留空，由消息文件提供
+	assert.Contains(t, output, `DefaultMessage: &i18n.Message{ID: "nhsj", Description: "你好世界", Other: ""}`)
+	assert.Contains(t, output, `DefaultMessage: &i18n.Message{ID: "dy", Description: "第{{.Arg0}}页", Other: ""}, TemplateData: map[string]any{"Arg0": n}`)
+	typeCheck(t, output)
+
+	// Description 中的原文不会被再次替换
+	again, msgs := transformString(t, output, opts)
+	assert.Empty(t, msgs)
+	assert.Equal(t, output, again)
+
+	// 可以指定占位文本
+	output, _ = transformString(t, input, Options{SourceAsDescription: true, OtherPlaceholder: "TODO"})
+	assert.Contains(t, output, `DefaultMessage: &i18n.Message{ID: "nhsj", Description: "你好世界", Other: "TODO"}`)
+}
+
+func TestSourceAsDescriptionBundle(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+	assert.Equal(t, exitChanges, run([]string{"extract", "-source-as-description", "-bundle-out", bundlePath, input}))
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"nhsj": {"description": "你好世界", "other": ""}}, b)
+
+	// 人工填写的 other 不会被占位文本覆盖，转换后重新提取时同样保留
+	writeTestFile(t, tempDir, "active.zh.toml", "[nhsj]\ndescription = \"你好世界\"\nother = \"您好，世界\"\n")
+	assert.Equal(t, exitChanges, run([]string{"fix", "-source-as-description", "-bundle-out", bundlePath, input}))
+	assert.Equal(t, exitOK, run([]string{"extract", "-source-as-description", "-bundle-out", bundlePath, input}))
+	b, err = loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"nhsj": {"description": "你好世界", "other": "您好，世界"}}, b)
+
+	assert.Equal(t, exitUsage, run([]string{"fix", "-source-as-description", "-annotate-position", input}))
+	assert.Equal(t, exitUsage, run([]string{"fix", "-other-placeholder", "TODO", input}))
+}
+
+func TestMessageField(t *testing.T) {
+	input := `package main
+
+func example() string {
+	return "你好世界"
+}`
+	opts := Options{MessageField: "Text"}
+
+	output, msgs := transformString(t, input, opts)
+	assert.Equal(t, []Message{{ID: "nhsj", Other: "你好世界"}}, msgs)
+	assert.Contains(t, output, `DefaultMessage: &i18n.Message{ID: "nhsj", Text: "你好世界"}`)
+	assert.NotContains(t, output, "Other:")
+
+	// 已在自定义字段中的字符串不会重复替换
+	again, msgs := transformString(t, output, opts)
+	assert.Empty(t, msgs)
+	assert.Equal(t, output, again)
+
+	// i18n.Message 中的字符串总是跳过；其他消息类型默认只跳过 Other 字段
+	_, msgs = transformString(t, output, Options{})
+	assert.Empty(t, msgs)
+	custom := `package main
+
+var m = &messages.Message{ID: "nhsj", Text: "你好世界"}`
+	_, msgs = transformString(t, custom, Options{})
+	assert.Len(t, msgs, 1)
+	_, msgs = transformString(t, custom, opts)
+	assert.Empty(t, msgs)
+
+	// 模板消息同样使用自定义字段
+	output, _ = transformString(t, `package main
+
+import "fmt"
+
+func greet(name string) string {
+	return fmt.Sprintf("你好%s", name)
+}`, Options{MessageField: "Text", ConvertSprintf: true})
+	assert.Contains(t, output, `&i18n.Message{ID: "nh", Text: "你好{{.Arg0}}"}`)
+
+	assert.NoError(t, validateMessageField(""))
+	assert.NoError(t, validateMessageField("DefaultText"))
+	assert.Error(t, validateMessageField("text"))
+	assert.Error(t, validateMessageField("Other Text"))
+}
+
+func TestSkipLiteralsInsideI18nComposites(t *testing.T) {
+	input := `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example(name string) string {
+	return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{
+		MessageID:      "问候",
+		DefaultMessage: &i18n.Message{ID: "问候", Description: "首页的问候语", Other: "你好{{.Name}}"},
+		TemplateData:   map[string]any{"Name": "访客"},
+	})
+}`
+
+	// 消息ID、原文和 Description 都不处理，只替换 TemplateData 中的值
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{{ID: "fk", Other: "访客"}}, msgs)
+	assert.Contains(t, output, `MessageID:	"问候",`)
+	assert.Contains(t, output, `&i18n.Message{ID: "问候", Description: "首页的问候语", Other: "你好{{.Name}}"}`)
+	assert.Contains(t, output, `"Name": i18n.Localizer.MustLocalize(`)
+
+	// 点导入和重命名导入时同样识别
+	dot := strings.Replace(strings.ReplaceAll(input, "i18n.", ""), `import "github.com`, `import . "github.com`, 1)
+	_, msgs = transformString(t, dot, Options{})
+	assert.Equal(t, []Message{{ID: "fk", Other: "访客"}}, msgs)
+	renamed := strings.Replace(strings.ReplaceAll(input, "i18n.", "goi18n."), `import "github.com`, `import goi18n "github.com`, 1)
+	_, msgs = transformString(t, renamed, Options{})
+	assert.Equal(t, []Message{{ID: "fk", Other: "访客"}}, msgs)
+
+	// 注释指定的中文ID不合法，改用生成的ID，重新运行时生成的调用保持不变
+	annotated := `package main
+
+func example() string {
+	return "你好" //i18n:id=问候
+}`
+	output, msgs = transformString(t, annotated, Options{})
+	assert.Equal(t, []Message{{ID: "nh", Other: "你好"}}, msgs)
+	again, msgs := transformString(t, output, Options{})
+	assert.Empty(t, msgs)
+	assert.Equal(t, output, again)
+}
+
+func TestLabeledStatements(t *testing.T) {
+	input := `package main
+
+func show(string) {}
+
+func example(rows [][]int) {
+外层:
+	for _, row := range rows {
+		for _, v := range row {
+			if v < 0 {
+				show("跳过负数")
+				continue 外层
+			}
+			if v == 0 {
+				goto 结束
+			}
+		}
+	}
+结束:
+	show("处理完成")
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "tgfs", Other: "跳过负数"},
+		{ID: "clwc", Other: "处理完成"},
+	}, msgs)
+	// 标签是标识符，保持不变
+	assert.Contains(t, output, "\n外层:\n\tfor _, row := range rows {")
+	assert.Contains(t, output, "continue 外层\n")
+	assert.Contains(t, output, "goto 结束\n")
+	assert.Contains(t, output, "\n结束:\n\tshow(i18n.Localizer.MustLocalize(")
+	typeCheck(t, output)
+}
+
+func TestSelectStatements(t *testing.T) {
+	input := `package main
+
+func log(string) {}
+
+func example(in <-chan int, out chan<- string, done chan struct{}) {
+	select {
+	case x := <-in:
+		log("收到数据")
+		_ = x
+	case out <- "发送消息":
+	case <-done:
+		return
+	default:
+		log("没有数据")
+	}
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "sdsj", Other: "收到数据"},
+		{ID: "fsxx", Other: "发送消息"},
+		{ID: "mysj", Other: "没有数据"},
+	}, msgs)
+	// 各个分支的结构保持不变，发送语句中的值同样替换
+	assert.Contains(t, output, "\tcase x := <-in:\n\t\tlog(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "\tcase out <- i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "\tcase <-done:\n\t\treturn\n")
+	assert.Contains(t, output, "\tdefault:\n\t\tlog(i18n.Localizer.MustLocalize(")
+	typeCheck(t, output)
+}
+
+func TestCallbackArguments(t *testing.T) {
+	input := `package main
+
+import "sort"
+
+func log(string) {}
+
+func example(names []string, each func(func(string) error)) {
+	sort.Slice(names, func(i, j int) bool {
+		log("比较")
+		return names[i] < names[j]
+	})
+	each(func(name string) error {
+		return func() error {
+			log("内层回调")
+			return nil
+		}()
+	})
+	go func(msg string) {
+		log(msg)
+	}("后台任务")
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "bj", Other: "比较"},
+		{ID: "nchd", Other: "内层回调"},
+		{ID: "htrw", Other: "后台任务"},
+	}, msgs)
+	// 作为参数的函数字面量保持原样，其中的字符串同样替换
+	assert.Contains(t, output, "\tsort.Slice(names, func(i, j int) bool {\n\t\tlog(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "\t\treturn names[i] < names[j]\n\t})")
+	assert.Contains(t, output, "\t}(i18n.Localizer.MustLocalize(")
+	typeCheckWith(t, output, map[string]string{"sort": "package sort\n\nfunc Slice(x any, less func(i, j int) bool) {}\n"})
+}
+
+func TestMultiValueAssignments(t *testing.T) {
+	input := `package main
+
+func pair() (string, string) {
+	a, b := "中文一", "中文二"
+	var c, d = "中文三", 1
+	a, b = b, "其他"
+	return a + c, b[d:]
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "zwy", Other: "中文一"},
+		{ID: "zwe", Other: "中文二"},
+		{ID: "zws", Other: "中文三"},
+		{ID: "qt", Other: "其他"},
+	}, msgs)
+
+	// 每个字符串各自替换，左右两边的数量不变
+	_, file := parseSource(t, output)
+	var assigns []*ast.AssignStmt
+	var specs []*ast.ValueSpec
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			assigns = append(assigns, n)
+		case *ast.ValueSpec:
+			specs = append(specs, n)
+		}
+		return true
+	})
+	if assert.Len(t, assigns, 2) && assert.Len(t, specs, 1) {
+		for _, assign := range assigns {
+			assert.Len(t, assign.Lhs, 2)
+			assert.Len(t, assign.Rhs, 2)
+		}
+		assert.IsType(t, &ast.CallExpr{}, assigns[0].Rhs[0])
+		assert.IsType(t, &ast.CallExpr{}, assigns[0].Rhs[1])
+		assert.IsType(t, &ast.Ident{}, assigns[1].Rhs[0])
+		assert.IsType(t, &ast.CallExpr{}, assigns[1].Rhs[1])
+		assert.Len(t, specs[0].Names, 2)
+		assert.Len(t, specs[0].Values, 2)
+		assert.IsType(t, &ast.BasicLit{}, specs[0].Values[1])
+	}
+	typeCheck(t, output)
+}
+
+func TestConversions(t *testing.T) {
+	input := `package main
+
+type Label string
+
+const title = Label("标题")
+
+func convert(v interface{}) (string, any, []byte, Label, bool) {
+	s, ok := v.(string)
+	_ = s
+	return string("中文"), any("任意"), []byte("字节"), Label("标签"), ok
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "zw", Other: "中文"},
+		{ID: "ry", Other: "任意"},
+		{ID: "zj", Other: "字节"},
+		{ID: "bq", Other: "标签"},
+	}, msgs)
+	// 替换转换中的字符串，转换本身保持不变；多余的 string(...) 也保留，由开发者决定是否删除
+	assert.Contains(t, output, "return string(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "any(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "[]byte(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "Label(i18n.Localizer.MustLocalize(")
+	// 常量中的转换不能调用函数，保持不变
+	assert.Contains(t, output, `const title = Label("标题")`)
+	assert.Contains(t, output, "s, ok := v.(string)")
+	typeCheck(t, output)
+}
+
+func TestNonStringLiterals(t *testing.T) {
+	input := `package main
+
+func example() (rune, int, float64, complex128) {
+	r := '中'
+	return r, 42, 3.14, 2i
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Empty(t, msgs)
+	assert.Contains(t, output, "r := '中'")
+	assert.NotContains(t, output, "i18n")
+
+	// 只处理 token.STRING，即使其他字面量的值中包含中文也不替换
+	for _, kind := range []token.Token{token.INT, token.FLOAT, token.IMAG, token.CHAR} {
+		fset, file := parseSource(t, "package main\n\nvar v = 0\n")
+		spec := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)
+		lit := &ast.BasicLit{ValuePos: spec.Values[0].Pos(), Kind: kind, Value: "中文"}
+		spec.Values[0] = lit
+
+		found, _ := stringLiteral(lit)
+		assert.Nil(t, found, kind.String())
+		msgs, err := transform(file, fset, Options{})
+		assert.NoError(t, err)
+		assert.Empty(t, msgs, kind.String())
+		assert.Same(t, lit, spec.Values[0], kind.String())
+	}
+}
+
+func TestCallValuedStructFields(t *testing.T) {
+	input := `package main
+
+type Config struct {
+	Name   string
+	Labels map[string]string
+	Items  []Item
+}
+
+type Item struct{ Title string }
+
+func getDefault(s string) string { return s }
+
+var cfg = Config{
+	Name:   getDefault("默认名称"),
+	Labels: map[string]string{"字面键": getDefault("值"), getDefault("计算键"): "其他"},
+	Items:  []Item{{Title: getDefault("标题")}},
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "mrmc", Other: "默认名称"},
+		{ID: "z", Other: "值"},
+		{ID: "jsj", Other: "计算键"},
+		{ID: "qt", Other: "其他"},
+		{ID: "bt", Other: "标题"},
+	}, msgs)
+	// 字段值中的调用参数照常替换
+	assert.Contains(t, output, "Name:\tgetDefault(i18n.Localizer.MustLocalize(")
+	assert.Contains(t, output, "[]Item{{Title: getDefault(i18n.Localizer.MustLocalize(")
+	// 只有直接作为键的字面量被跳过，键中调用的参数不是键本身，照常替换
+	assert.Contains(t, output, `"字面键": getDefault(i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `getDefault(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "jsj"`)
+	typeCheck(t, output)
+}
+
+func TestMapLiteralValues(t *testing.T) {
+	input := `package main
+
+func handler(c *gin.Context) {
+	c.JSON(200, gin.H{"message": "成功", "中文键": "值"})
+	c.JSON(500, map[string]interface{}{"msg": "失败", "data": []interface{}{"元素"}})
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "cg", Other: "成功"},
+		{ID: "z", Other: "值"},
+		{ID: "sb", Other: "失败"},
+		{ID: "ys", Other: "元素"},
+	}, msgs)
+	assert.Contains(t, output, `gin.H{"message": i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `"中文键": i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `map[string]interface{}{"msg": i18n.Localizer.MustLocalize(`)
+
+	// 生成的代码可以重新解析
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+
+	_, msgs = transformString(t, input, Options{WrapIndexKeys: true})
+	assert.Len(t, msgs, 5)
+}
+
+func TestGenericsAndEmbeddedStructs(t *testing.T) {
+	input := `package main
+
+type Base struct {
+	Name string
+}
+
+type Outer struct {
+	Base ` + "`json:\"基础\"`" + `
+	Pair[string]
+}
+
+type Pair[T any] struct {
+	First T
+}
+
+type Text interface {
+	~string
+}
+
+func Show[T Text](v T) string {
+	return "泛型函数" + string(v)
+}
+
+func example() {
+	o := Outer{Base: Base{Name: "嵌入字段"}, Pair: Pair[string]{First: "泛型结构体"}}
+	s := Show[string]("实例化调用")
+	_, _ = o, s
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	var others []string
+	for _, msg := range msgs {
+		others = append(others, msg.Other)
+	}
+	assert.Equal(t, []string{"泛型函数", "嵌入字段", "泛型结构体", "实例化调用"}, others)
+	// 嵌入字段的标签保持不变
+	assert.Contains(t, output, "Base\t`json:\"基础\"`")
+
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+}
+
+func TestConstDecl(t *testing.T) {
+	input := `package main
+
+type Status string
+
+const Active Status = "启用"
+
+const (
+	Disabled Status = "停用"
+	title           = "标题" + "后缀"
+)
+
+func example() string {
+	const local = "局部常量"
+	var s Status = Active
+	_ = s
+	return "变量"
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{{ID: "bl", Other: "变量"}}, msgs)
+	for _, want := range []string{
+		`const Active Status = "启用"`,
+		`= "停用"`,
+		`= "标题" + "后缀"`,
+		`const local = "局部常量"`,
+	} {
+		assert.Contains(t, output, want)
+	}
+}
+
+func TestByteConversion(t *testing.T) {
+	input := `package main
+
+func example() {
+	b := []byte("中文数据")
+	r := []rune("中文字符")
+}`
+
+	// 默认替换，替换后仍是合法的 []byte 转换
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "zwsj", Other: "中文数据"},
+		{ID: "zwzf", Other: "中文字符"},
+	}, msgs)
+	assert.Contains(t, output, `b := []byte(i18n.Localizer.MustLocalize(`)
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+
+	output, msgs = transformString(t, input, Options{SkipByteConversions: true})
+	assert.Equal(t, []Message{{ID: "zwzf", Other: "中文字符"}}, msgs)
+	assert.Contains(t, output, `b := []byte("中文数据")`)
+}
+
+func TestQuoteOther(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "raw string with quotes",
+			input: `package main
+
+func example() {
+	s := ` + "`他说\"你好\"`" + `
+}`,
+			expected: "Other: `他说\"你好\"`",
+		},
+		{
+			name: "interpreted string with escaped quotes",
+			input: `package main
+
+func example() {
+	s := "他说\"你好\""
+}`,
+			expected: "Other: `他说\"你好\"`",
+		},
+		{
+			name: "quotes and newline",
+			input: `package main
+
+func example() {
+	s := "他说\"你好\"\n"
+}`,
+			expected: `Other: "他说\"你好\"\n"`,
+		},
+		{
+			name: "raw string with backslash",
+			input: `package main
+
+func example() {
+	s := ` + "`路径 C:\\数据`" + `
+}`,
+			expected: `Other: "路径 C:\\数据"`,
+		},
+		{
+			name: "many backslashes",
+			input: `package main
+
+func example() {
+	s := "路径 C:\\数据\\文件"
+}`,
+			expected: "Other: `路径 C:\\数据\\文件`",
+		},
+		{
+			name: "quotes and backslashes",
+			input: `package main
+
+func example() {
+	s := "匹配 \"\\d+\""
+}`,
+			expected: "Other: `匹配 \"\\d+\"`",
+		},
+		{
+			name: "quotes and backtick",
+			input: `package main
+
+func example() {
+	s := "运行 \"` + "`ls`" + `\" 命令"
+}`,
+			expected: `Other: "运行 \"` + "`ls`" + `\" 命令"`,
+		},
+		{
+			name: "single and double quotes",
+			input: `package main
+
+func example() {
+	s := "他说'好'和\"行\""
+}`,
+			expected: "Other: `他说'好'和\"行\"`",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _ := transformString(t, tt.input, Options{})
+			assert.Contains(t, output, tt.expected)
+
+			// 输出必须仍然是合法的 Go 代码
+			_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+// markerDetector 只替换作为 tr(...) 参数的字符串
+type markerDetector struct{}
+
+func (markerDetector) ShouldWrap(lit *ast.BasicLit, cursor *astutil.Cursor) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := call.Fun.(*ast.Ident)
+	return ok && fn.Name == "tr"
+}
+
+func TestCustomDetector(t *testing.T) {
+	input := `package main
+
+func example() {
+	a := tr("Hello")
+	b := "你好世界"
+	c := tr("提示" + "信息")
+}`
+
+	_, msgs := transformString(t, input, Options{Detector: markerDetector{}})
+	assert.Equal(t, []Message{
+		{ID: "hello", Other: "Hello"},
+		{ID: "tsxx", Other: "提示信息"},
+	}, msgs)
+
+	_, msgs = transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "tsxx", Other: "提示信息"},
+	}, msgs)
+}
+
+func TestHTMLAware(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		htmlAware bool
+		expected  string
+	}{
+		{
+			name:      "bold fragment",
+			input:     `"<b>提示</b>"`,
+			htmlAware: true,
+			expected:  "ts",
+		},
+		{
+			name:      "attribute text ignored",
+			input:     `"<a title=\"链接说明\">点击这里</a>"`,
+			htmlAware: true,
+			expected:  "djzl",
+		},
+		{
+			name:      "attribute text used without html-aware",
+			input:     `"<a title=\"链接说明\">点击这里</a>"`,
+			htmlAware: false,
+			expected:  "ljsmd",
+		},
+		{
+			name:      "comparison is not a tag",
+			input:     `"数量 < 10 > 5"`,
+			htmlAware: true,
+			expected:  "sl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateMessageID(tt.input, Options{HTMLAware: tt.htmlAware})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestHTMLAwareKeepsOriginalOther(t *testing.T) {
+	output, msgs := transformString(t, `package main
+
+func example() {
+	s := "<b>提示</b>：请先登录"
+}`, Options{HTMLAware: true})
+
+	assert.Equal(t, []Message{{ID: "tsqxd", Other: "<b>提示</b>：请先登录"}}, msgs)
+	assert.Contains(t, output, `Other: "<b>提示</b>：请先登录"`)
+}
+
+func TestHTMLTypedAware(t *testing.T) {
+	input := `package main
+
+import "html/template"
+
+func example() (template.HTML, string) {
+	return template.HTML("<a title=\"链接说明\">点击这里</a>"), "<a title=\"帮助\">查看</a>"
+}`
+
+	output, msgs := transformString(t, input, Options{HTMLTypedAware: true})
+	assert.Equal(t, []Message{
+		{ID: "djzl", Other: `<a title="链接说明">点击这里</a>`},
+		{ID: "bzck", Other: `<a title="帮助">查看</a>`},
+	}, msgs)
+	// 替换后仍是 string 到 template.HTML 的转换
+	assert.Contains(t, output, `template.HTML(i18n.Localizer.MustLocalize(`)
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+
+	_, msgs = transformString(t, input, Options{})
+	assert.Equal(t, "ljsmd", msgs[0].ID)
+}
+
+func TestSinks(t *testing.T) {
+	input := `package main
+
+func example(w io.Writer, c *gin.Context) {
+	fmt.Fprintf(w, "欢迎回来")
+	c.JSON(200, "操作成功")
+	key := "内部缓存键"
+	log.Println("调试信息")
+	fmt.Fprintf(w, "%s", format("嵌套参数"))
+}`
+
+	_, msgs := transformString(t, input, Options{Sinks: []string{"fmt.Fprintf", "c.JSON"}})
+	assert.Equal(t, []Message{
+		{ID: "hyhl", Other: "欢迎回来"},
+		{ID: "czcg", Other: "操作成功"},
+	}, msgs)
+
+	_, msgs = transformString(t, input, Options{})
+	assert.Len(t, msgs, 5)
+}
+
+func TestLimit(t *testing.T) {
+	input := `package main
+
+func example() {
+	a := "第一条"
+	b := "第二条" //i18n:id=second
+	c := "第三条"
+	d := "第五条"
+}`
+
+	output, msgs := transformString(t, input, Options{Limit: 2})
+	assert.Equal(t, []Message{
+		{ID: "dyt", Other: "第一条"},
+		{ID: "second", Other: "第二条"},
+	}, msgs)
+	assert.Contains(t, output, `c := "第三条"`)
+	assert.Contains(t, output, `d := "第五条"`)
+
+	// 再次运行时跳过已替换的字符串，继续替换剩余的字符串
+	output, msgs = transformString(t, output, Options{Limit: 2})
+	assert.Equal(t, []Message{
+		{ID: "dst", Other: "第三条"},
+		{ID: "dwt", Other: "第五条"},
+	}, msgs)
+	assert.NotContains(t, output, `c := "第三条"`)
+}
+
+// 超出数量限制的字符串不影响后面字符串的ID注释
+func TestLimitKeepsAnnotations(t *testing.T) {
+	_, msgs := transformString(t, `package main
+
+func example() {
+	a := "第一条"
+	b := "第二条" //i18n:id=second
+	c := "第三条"
+}`, Options{Limit: 1})
+	assert.Equal(t, []Message{{ID: "dyt", Other: "第一条"}}, msgs)
+}
+
+// 分批替换时已有调用使用的ID被预留，后续批次中生成相同ID的原文追加后缀，不覆盖消息文件中的原文
+func TestLimitReservesExistingIDs(t *testing.T) {
+	tempDir := t.TempDir()
+	path := writeTestFile(t, tempDir, "main.go", `package main
+
+func example() {
+	a := "你好世界"
+	b := "你好时间"
+}`)
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+
+	assert.Equal(t, exitChanges, run([]string{"-w", "-limit", "1", "-bundle-out", bundlePath, path}))
+	assert.Equal(t, exitChanges, run([]string{"-w", "-limit", "1", "-bundle-out", bundlePath, path}))
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	if assert.Len(t, b, 2) {
+		assert.Equal(t, "你好世界", b["nhsj"]["other"])
+		assert.Equal(t, "你好时间", b["nhsj_"+hashSuffix("你好时间")]["other"])
+	}
+
+	// 与已有调用相同的原文沿用其ID，不追加后缀
+	_, msgs := transformString(t, `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+var localizer *i18n.Localizer
+
+func example() {
+	a := localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
+	b := "你好世界"
+	c := "你好时间"
+}`, Options{})
+	assert.Equal(t, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "nhsj_" + hashSuffix("你好时间"), Other: "你好时间"},
+	}, msgs)
+}
+
+// 生成的调用不应打乱原有注释的位置，Other 使用新的字面量而不是原节点
+func TestCommentsNearLiteral(t *testing.T) {
+	input := `package main
+
+func example(name string) {
+	// 上一行注释
+	a := "你好世界" // 问候
+	b := fmt.Sprintf("欢迎 %s", name) // 尾注释
+	fmt.Println("第一条", // 参数后
+		"第二条")
+}`
+
+	fset, file := parseSource(t, input)
+	var original *ast.BasicLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok && lit.Value == `"你好世界"` {
+			original = lit
+		}
+		return true
+	})
+	_, err := transform(file, fset, Options{ConvertSprintf: true})
+	assert.NoError(t, err)
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if kv, ok := n.(*ast.KeyValueExpr); ok {
+			if key, ok := kv.Key.(*ast.Ident); ok && key.Name == "Other" {
+				assert.NotSame(t, original, kv.Value)
+			}
+		}
+		return true
+	})
+
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "\t// 上一行注释", lines[5])
+	assert.Regexp(t, `Other: "你好世界"}}\)\s+// 问候$`, lines[6])
+	assert.Regexp(t, `map\[string\]any{"Arg0": name}}\)\s+// 尾注释$`, lines[7])
+	assert.Regexp(t, `Other: "第一条"}}\),\s+// 参数后$`, lines[8])
+}
+
+func TestWrapParents(t *testing.T) {
+	input := `package main
+
+func example() string {
+	s := "赋值语句"
+	fmt.Println("函数参数")
+	m := map[string]string{"key": "键值"}
+	_, _ = s, m
+	return "返回值"
+}`
+
+	_, msgs := transformString(t, input, Options{WrapParents: []string{"AssignStmt"}})
+	assert.Equal(t, []Message{{ID: "fzyj", Other: "赋值语句"}}, msgs)
+
+	_, msgs = transformString(t, input, Options{WrapParents: []string{"CallExpr", "ReturnStmt"}})
+	assert.Equal(t, []Message{
+		{ID: "hscs", Other: "函数参数"},
+		{ID: "fhz", Other: "返回值"},
+	}, msgs)
+
+	assert.NoError(t, validateWrapParents([]string{"AssignStmt", "KeyValueExpr"}))
+	assert.Error(t, validateWrapParents([]string{"Assign"}))
+}
+
+func TestWrapArgs(t *testing.T) {
+	input := `package main
+
+func example() {
+	errorf("错误代码", "保存失败")
+	log.Errorf("日志前缀", "连接断开")
+	s := "其他字符串"
+}`
+
+	output, msgs := transformString(t, input, Options{WrapArgs: map[string]int{"errorf": 1, "log.Errorf": 1}})
+	assert.Equal(t, []Message{
+		{ID: "bcsb", Other: "保存失败"},
+		{ID: "ljdk", Other: "连接断开"},
+		{ID: "qtzfc", Other: "其他字符串"},
+	}, msgs)
+	assert.Contains(t, output, `errorf("错误代码", i18n.Localizer`)
+	assert.Contains(t, output, `log.Errorf("日志前缀", i18n.Localizer`)
+
+	positions, err := parseArgPositions("errorf:1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"errorf": 1}, positions)
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"fmt.Fprintf", "c.JSON"}, splitList(" fmt.Fprintf, ,c.JSON,"))
+	assert.Empty(t, splitList(""))
+}
+
+func TestAnnotatePosition(t *testing.T) {
+	src := `package main
 
 func example() {
-	s := "Hello World"
-}`,
-		},
-		{
-			name: "ignore struct tags",
-			input: `package main
+	s := "你好世界"
+}`
 
-type Person struct {
-	Name string ` + "`json:\"姓名\"`" + `
-}`,
-			expected: `package main
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "service/user.go", src, parser.ParseComments)
+	assert.NoError(t, err)
 
-type Person struct {
-	Name string ` + "`json:\"姓名\"`" + `
-}`,
-		},
-		{
-			name: "ignore wrapped string",
-			input: `package main
+	transform(file, fset, Options{AnnotatePosition: true})
 
-import "github.com/nicksnyder/go-i18n/v2/i18n"
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.Contains(t, buf.String(), `&i18n.Message{ID: "nhsj", Description: "service/user.go:4", Other: "你好世界"}`)
 
-func example() {
-	s := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
-}`,
-			expected: `package main
+	output, _ := transformString(t, src, Options{})
+	assert.NotContains(t, output, "Description")
+}
 
-import "github.com/nicksnyder/go-i18n/v2/i18n"
+func TestPrintFileIndentation(t *testing.T) {
+	src := `package main
 
 func example() {
-	s := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
-}`,
+	if true {
+		s := "你好世界"
+	}
+}`
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "default tabs",
+			opts:     Options{},
+			expected: "\n\t\ts := i18n.",
 		},
 		{
-			name: "ignore Chinese in comments",
-			input: `package main
-
-// 这是一个中文注释
-func example() {
-	// 另一个中文注释
-	s := "Hello"
-	/* 这也是中文注释 */
-}`,
-			expected: `package main
-
-// 这是一个中文注释
-func example() {
-	// 另一个中文注释
-	s := "Hello"
-	/* 这也是中文注释 */
-}`,
+			name:     "spaces with width 4",
+			opts:     Options{UseSpaces: true, TabWidth: 4},
+			expected: "\n        s := i18n.",
+		},
+		{
+			name:     "spaces with width 2",
+			opts:     Options{UseSpaces: true, TabWidth: 2},
+			expected: "\n    s := i18n.",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fset := token.NewFileSet()
-			file, err := parser.ParseFile(fset, "", tt.input, parser.ParseComments)
-			assert.NoError(t, err)
+			fset, file := parseSource(t, src)
+			transform(file, fset, tt.opts)
 
-			transform(file, fset)
+			var buf bytes.Buffer
+			assert.NoError(t, printFile(&buf, fset, file, tt.opts))
+			assert.Contains(t, buf.String(), tt.expected)
+		})
+	}
+}
 
-			// 将转换后的 AST 转换回字符串
-			var buf strings.Builder
-			err = printer.Fprint(&buf, fset, file)
-			assert.NoError(t, err)
+func TestLongString(t *testing.T) {
+	paragraph := strings.Repeat("这是一个很长的段落，", 200)
+	input := `package main
 
-			// 规范化字符串（移除多余的空白字符）
-			normalizedResult := strings.TrimSpace(buf.String())
-			normalizedExpected := strings.TrimSpace(tt.expected)
+func example() {
+	a := "` + paragraph + `结尾一"
+	b := "` + paragraph + `结尾二"
+}`
 
-			assert.Equal(t, normalizedExpected, normalizedResult)
-		})
+	output, msgs := transformString(t, input, Options{})
+	if !assert.Len(t, msgs, 2) {
+		return
 	}
+	// ID 的长度与原文长度无关，冲突时只追加固定长度的哈希后缀
+	assert.Equal(t, "zsygh", msgs[0].ID)
+	assert.Equal(t, "zsygh_"+hashSuffix(paragraph+"结尾二"), msgs[1].ID)
+	assert.Equal(t, paragraph+"结尾一", msgs[0].Other)
+
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, output, `Other: "`+paragraph+`结尾一"`)
+}
+
+// 很长且无法组成ID的文本不会让ID无限增长，也不会产生空ID
+func TestGenerateMessageIDLongInput(t *testing.T) {
+	assert.Equal(t, "msg", generateMessageID(strconv.Quote(strings.Repeat("😀", 2000)), Options{}))
+	assert.Equal(t, "hello", generateMessageID(strconv.Quote(strings.Repeat("hello", 2000)), Options{}))
 }
 
 func TestGenerateMessageID(t *testing.T) {
@@ -205,10 +1956,209 @@ func TestGenerateMessageID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := generateMessageID(tt.input)
+			result := generateMessageID(tt.input, Options{})
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGenerateMessageIDCase(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		idCase   string
+		expected string
+	}{
+		{
+			name:     "snake case",
+			input:    `"你好世界"`,
+			idCase:   idCaseSnake,
+			expected: "n_h_s_j",
+		},
+		{
+			name:     "camel case",
+			input:    `"你好世界"`,
+			idCase:   idCaseCamel,
+			expected: "nHSJ",
+		},
+		{
+			name:     "pascal case",
+			input:    `"你好世界"`,
+			idCase:   idCasePascal,
+			expected: "NHSJ",
+		},
+		{
+			name:     "snake case with English words",
+			input:    `"go run"`,
+			idCase:   idCaseSnake,
+			expected: "go_run",
+		},
+		{
+			name:     "camel case with English words",
+			input:    `"go run"`,
+			idCase:   idCaseCamel,
+			expected: "goRun",
+		},
+		{
+			name:     "pascal case fallback still starts with letter",
+			input:    `"123"`,
+			idCase:   idCasePascal,
+			expected: "Msg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateMessageID(tt.input, Options{IDCase: tt.idCase})
+			assert.Equal(t, tt.expected, result)
+			assert.Regexp(t, `^[a-zA-Z]`, result)
+		})
+	}
+}
+
+func TestPinyinStyleAndSeparator(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		style     string
+		separator string
+		idCase    string
+		expected  string
+	}{
+		{
+			name:     "first letter",
+			input:    `"你好世界"`,
+			expected: "nhsj",
+		},
+		{
+			name:      "first letter with separator",
+			input:     `"你好世界"`,
+			style:     pinyinStyleFirstLetter,
+			separator: "-",
+			expected:  "n-h-s-j",
+		},
+		{
+			name:      "full pinyin with default separator",
+			input:     `"你好世界"`,
+			style:     pinyinStyleFull,
+			separator: defaultIDSeparator(pinyinStyleFull),
+			expected:  "ni_hao_shi_jie",
+		},
+		{
+			name:      "full pinyin with dot",
+			input:     `"绿色"`,
+			style:     pinyinStyleFull,
+			separator: ".",
+			expected:  "lv.se",
+		},
+		{
+			name:     "full pinyin without separator",
+			input:    `"你好"`,
+			style:    pinyinStyleFull,
+			expected: "nihao",
+		},
+		{
+			name:      "id case takes precedence over separator",
+			input:     `"你好"`,
+			style:     pinyinStyleFull,
+			separator: "-",
+			idCase:    idCaseCamel,
+			expected:  "niHao",
+		},
+		{
+			name:      "separator between English words",
+			input:     `"go run"`,
+			style:     pinyinStyleFull,
+			separator: "_",
+			expected:  "go_run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := generateMessageID(tt.input, Options{PinyinStyle: tt.style, IDSeparator: tt.separator, IDCase: tt.idCase})
 			assert.Equal(t, tt.expected, result)
+			assert.NoError(t, validateMessageID(result))
 		})
 	}
+
+	assert.Equal(t, "", defaultIDSeparator(pinyinStyleFirstLetter))
+	assert.NoError(t, validatePinyinStyle(pinyinStyleFull))
+	assert.Error(t, validatePinyinStyle("tone"))
+	for _, sep := range []string{"", "_", "-", ".", "__"} {
+		assert.NoError(t, validateIDSeparator(sep), sep)
+	}
+	for _, sep := range []string{" ", "/", "：", "+"} {
+		assert.Error(t, validateIDSeparator(sep), sep)
+	}
+}
+
+func TestHashIDScheme(t *testing.T) {
+	opts := Options{IDScheme: idSchemeHash}
+	id := generateMessageID(`"你好世界"`, opts)
+	assert.Regexp(t, `^msg_[0-9a-f]{8}$`, id)
+	// 同一原文总是得到相同的ID，与拼音相关的选项无关
+	assert.Equal(t, id, generateMessageID(`"你好世界"`, opts))
+	assert.Equal(t, id, generateMessageID(`"你好世界"`, Options{IDScheme: idSchemeHash, PinyinStyle: pinyinStyleFull, IDCase: idCaseCamel, IDLength: 2}))
+	assert.Equal(t, "msg_"+hashSuffix("你好世界"), id)
+	// 由完整原文计算，开头相同、首尾空白不同的原文得到不同的ID
+	assert.NotEqual(t, id, generateMessageID(`"你好世界和平"`, opts))
+	assert.NotEqual(t, id, generateMessageID(`" 你好世界"`, opts))
+	// 按解码后的原文计算
+	assert.Equal(t, "msg_"+hashSuffix("第一行\n第二行"), generateMessageID(strconv.Quote("第一行\n第二行"), opts))
+
+	input := `package main
+
+var a = "你好世界"
+var b = "你好世界和平"
+var c = "你好世界"`
+	output, msgs := transformString(t, input, opts)
+	assert.Equal(t, []Message{
+		{ID: id, Other: "你好世界"},
+		{ID: generateMessageID(`"你好世界和平"`, opts), Other: "你好世界和平"},
+		{ID: id, Other: "你好世界"},
+	}, msgs)
+	assert.Contains(t, output, `MessageID: "`+id+`"`)
+	// 重新运行得到相同的结果
+	again, _ := transformString(t, input, opts)
+	assert.Equal(t, output, again)
+
+	path := writeTestFile(t, t.TempDir(), "input.go", input)
+	assert.Equal(t, exitUsage, run([]string{"-id-scheme", "md5", path, path + ".out"}))
+	assert.Equal(t, exitChanges, run([]string{"-id-scheme", "hash", path, path + ".out"}))
+	data, err := os.ReadFile(path + ".out")
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "`+id+`"`)
+}
+
+func TestPinyinStyleFlag(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好"
+`)
+	output := filepath.Join(tempDir, "output.go")
+
+	// 未指定分隔符时完整拼音使用 _，显式指定为空时直接拼接
+	assert.Equal(t, exitChanges, run([]string{"-pinyin-style", "full", input, output}))
+	data, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "ni_hao"`)
+
+	assert.Equal(t, exitChanges, run([]string{"-pinyin-style", "full", "-id-separator", "", "-force", input, output}))
+	data, err = os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "nihao"`)
+
+	assert.Equal(t, exitUsage, run([]string{"-id-separator", " ", input, output}))
+	assert.Equal(t, exitUsage, run([]string{"-pinyin-style", "tone", input, output}))
+}
+
+func TestValidateIDCase(t *testing.T) {
+	for _, style := range []string{"", idCaseSnake, idCaseCamel, idCasePascal} {
+		assert.NoError(t, validateIDCase(style))
+	}
+	assert.Error(t, validateIDCase("kebab"))
 }
 
 func TestIsInComment(t *testing.T) {
@@ -258,13 +2208,70 @@ func main() {
 			})
 
 			if stringLit != nil {
-				result := isInComment(stringLit, file, fset)
+				result := isInComment(stringLit, file)
 				assert.Equal(t, tt.expected, result)
 			}
 		})
 	}
 }
 
+func TestTrailingCommentOnSameLine(t *testing.T) {
+	input := `package main
+
+func example() {
+	s := "中文" // 注释 "中文"
+}`
+
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{{ID: "zw", Other: "中文"}}, msgs)
+	assert.Contains(t, output, `// 注释 "中文"`)
+	assert.NotContains(t, output, `s := "中文"`)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", input, parser.ParseComments)
+	assert.NoError(t, err)
+
+	oldStdout := os.Stdout
+	_, w, _ := os.Pipe()
+	os.Stdout = w
+	result := collectAndPrintChineseStrings(file, fset, Options{})
+	w.Close()
+	os.Stdout = oldStdout
+
+	assert.Equal(t, []string{"中文"}, result)
+}
+
+func TestCollectStrings(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "example.go", `package main
+
+type Person struct {
+	Name string `+"`json:\"姓名\"`"+`
+}
+
+// 中文注释
+func example() {
+	a := "你好世界"
+	b := "Hello"
+	c := "你好时间" //i18n:id=greeting_time
+}`, parser.ParseComments)
+	assert.NoError(t, err)
+
+	msgs := CollectStrings(file, fset)
+	assert.Len(t, msgs, 2)
+	assert.Equal(t, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "greeting_time", Other: "你好时间"},
+	}, stripPositions(append([]Message(nil), msgs...)))
+	assert.Equal(t, "example.go:9:7", msgs[0].Position.String())
+	assert.Equal(t, "example.go:11:7", msgs[1].Position.String())
+
+	// 收集不会修改语法树
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.NotContains(t, buf.String(), "MustLocalize")
+}
+
 func TestCollectAndPrintChineseStrings(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -322,7 +2329,7 @@ type Person struct {
 			os.Stdout = w
 
 			// 调用函数
-			result := collectAndPrintChineseStrings(file)
+			result := collectAndPrintChineseStrings(file, fset, Options{})
 
 			// 恢复标准输出
 			w.Close()
@@ -350,3 +2357,293 @@ type Person struct {
 		})
 	}
 }
+
+func TestEmptyAndCommentOnlyFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "empty body",
+			input: "package main\n",
+		},
+		{
+			name: "comments only",
+			input: `// Package main 包注释：包含中文说明
+package main
+
+/* 块注释："不是字符串" */
+
+// 文件末尾的注释
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 没有需要替换的字符串时原样输出，不添加导入
+			output, msgs := transformString(t, tt.input, Options{})
+			assert.Empty(t, msgs)
+			assert.Equal(t, tt.input, output)
+			assert.NotContains(t, output, "import")
+
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "", tt.input, parser.ParseComments)
+			assert.NoError(t, err)
+
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+			result := collectAndPrintChineseStrings(file, fset, Options{})
+			w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			io.Copy(&buf, r)
+			assert.Empty(t, result)
+			assert.Equal(t, "未找到中文字符串\n", buf.String())
+
+			// 命令行输出的文件与输入相同
+			tempDir := t.TempDir()
+			input := writeTestFile(t, tempDir, "input.go", tt.input)
+			outputPath := filepath.Join(tempDir, "output.go")
+			assert.Equal(t, 0, run([]string{input, outputPath}))
+			data, err := os.ReadFile(outputPath)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.input, string(data))
+		})
+	}
+}
+
+// benchmarkSource 生成一个包含中英文字符串、注释和结构体标签的较大源文件
+func benchmarkSource(funcs int) string {
+	var b strings.Builder
+	b.WriteString("package main\n\n")
+	b.WriteString("type User struct {\n\tName string `json:\"name\" label:\"用户名\"`\n}\n\n")
+	for i := 0; i < funcs; i++ {
+		b.WriteString("// 示例函数，包含多种字符串\n")
+		b.WriteString("func example")
+		b.WriteString(strconv.Itoa(i))
+		b.WriteString("() {\n")
+		b.WriteString("\ta := \"你好世界\"\n")
+		b.WriteString("\tb := \"Hello World\"\n")
+		b.WriteString("\tc := \"用户名或密码错误，请重新输入\"\n")
+		b.WriteString("\td := \"ff混合23\"\n")
+		b.WriteString("\tprintln(a, b, c, d, \"操作成功\")\n")
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkGenerateMessageID(b *testing.B) {
+	inputs := []string{`"你好世界"`, `"用户名或密码错误，请重新输入"`, `"Hello World"`, `"ff混合23"`}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			generateMessageID(input, Options{})
+		}
+	}
+}
+
+func BenchmarkGenerateMessageIDLongString(b *testing.B) {
+	input := strconv.Quote(strings.Repeat("这是一个很长的段落，", 200))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		generateMessageID(input, Options{})
+	}
+}
+
+func BenchmarkContainsChinese(b *testing.B) {
+	inputs := []string{`"你好世界"`, `"Hello World, this is a longer English sentence"`, `"ff混合23"`}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			containsChinese(input)
+		}
+	}
+}
+
+func BenchmarkTransform(b *testing.B) {
+	src := benchmarkSource(200)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "bench.go", src, parser.ParseComments)
+		if err != nil {
+			b.Fatalf("解析源码失败: %v", err)
+		}
+		transform(file, fset, Options{})
+	}
+}
+
+func BenchmarkExtractPinyinWords(b *testing.B) {
+	inputs := []string{"你好世界", "Hello World", "user_name 123", "ff混合23"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, input := range inputs {
+			extractPinyinWords(input, 5, pinyinStyleFirstLetter)
+		}
+	}
+}
+
+func BenchmarkExtractPinyinWordsParallel(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			extractPinyinWords("用户名或密码错误", 5, pinyinStyleFirstLetter)
+		}
+	})
+}
+
+// 多个 goroutine 同时生成ID的结果与顺序执行一致，配合 -race 检查共享的拼音参数
+func TestExtractPinyinWordsConcurrent(t *testing.T) {
+	inputs := []string{"你好世界", "用户名或密码错误", "银行卡号", "Hello World", "ff混合23"}
+	for _, style := range []string{pinyinStyleFirstLetter, pinyinStyleFull} {
+		t.Run(style, func(t *testing.T) {
+			expected := make([][]string, len(inputs))
+			for i, input := range inputs {
+				expected[i] = extractPinyinWords(input, 5, style)
+			}
+
+			var wg sync.WaitGroup
+			results := make([][][]string, 8)
+			for g := range results {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for _, input := range inputs {
+						results[g] = append(results[g], extractPinyinWords(input, 5, style))
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			for _, result := range results {
+				assert.Equal(t, expected, result)
+			}
+		})
+	}
+}
+
+func TestSubcommands(t *testing.T) {
+	content := `package main
+
+var greeting = "你好世界"
+`
+
+	t.Run("check", func(t *testing.T) {
+		path := writeTestFile(t, t.TempDir(), "input.go", content)
+		assert.Equal(t, 1, run([]string{"check", path}))
+		assert.Equal(t, 0, run([]string{"check", writeTestFile(t, t.TempDir(), "clean.go", "package main\n")}))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("fix", func(t *testing.T) {
+		path := writeTestFile(t, t.TempDir(), "input.go", content)
+		assert.Equal(t, exitChanges, run([]string{"fix", path}))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), `MessageID: "nhsj"`)
+	})
+
+	t.Run("fix -o", func(t *testing.T) {
+		tempDir := t.TempDir()
+		path := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+var notice = "你好时间"
+var again = "你好世界"
+`)
+		outputPath := filepath.Join(tempDir, "output.go")
+		bundlePath := filepath.Join(tempDir, "zh.toml")
+		// 参数可以写在文件之后
+		assert.Equal(t, exitChanges, run([]string{"fix", path, "-o", outputPath, "-bundle-out", bundlePath}))
+
+		// 输入文件不变，输出文件与消息文件中的消息ID一致
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(data), "MessageID")
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, outputPath, nil, 0)
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, err := loadBundle(bundlePath)
+		assert.NoError(t, err)
+		bundleIDs := map[string]bool{}
+		for id := range b {
+			bundleIDs[id] = true
+		}
+		assert.Len(t, bundleIDs, 2)
+		assert.Equal(t, bundleIDs, referencedMessageIDs([]*ast.File{file}, fset, nil))
+
+		// 输出文件已存在且内容不同时两者都不写入
+		other := writeTestFile(t, tempDir, "other.go", "package other\n")
+		otherBundle := filepath.Join(tempDir, "other.toml")
+		assert.Equal(t, exitError, run([]string{"fix", "-o", other, "-bundle-out", otherBundle, path}))
+		_, err = os.Stat(otherBundle)
+		assert.True(t, os.IsNotExist(err))
+
+		assert.Equal(t, exitUsage, run([]string{"fix", "-o", outputPath, path, path}))
+		assert.Equal(t, exitUsage, run([]string{"-o", outputPath, path, outputPath}))
+	})
+
+	t.Run("extract", func(t *testing.T) {
+		tempDir := t.TempDir()
+		path := writeTestFile(t, tempDir, "input.go", content)
+		bundlePath := filepath.Join(tempDir, "active.zh.toml")
+		assert.Equal(t, exitChanges, run([]string{"extract", "-bundle-out", bundlePath, path}))
+
+		// 只写入消息文件，源码保持不变
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(data))
+		b, err := loadBundle(bundlePath)
+		assert.NoError(t, err)
+		assert.Equal(t, bundle{"nhsj": {"other": "你好世界"}}, b)
+
+		assert.Equal(t, exitUsage, run([]string{"extract", path}))
+		assert.Equal(t, exitUsage, run([]string{"extract", "-revert", "-bundle-out", bundlePath, path}))
+	})
+}
+
+func TestExitCodes(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	clean := writeTestFile(t, tempDir, "clean.go", "package main\n")
+	rewrite := writeTestFile(t, tempDir, "rewrite.go", "package main\n\nvar greeting = \"你好世界\"\n")
+	bad := writeTestFile(t, tempDir, "bad.go", "package main\n\nvar = \n")
+	bundlePath := writeTestFile(t, tempDir, "active.zh.toml", "")
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected int
+	}{
+		{"successful transform", []string{input, filepath.Join(tempDir, "output.go")}, exitChanges},
+		{"nothing to transform", []string{clean, filepath.Join(tempDir, "clean_out.go")}, exitOK},
+		{"rewrite in place", []string{"-w", rewrite}, exitChanges},
+		{"rewrite unchanged", []string{"-w", rewrite}, exitOK},
+		{"clean check", []string{"check", clean}, exitOK},
+		{"check failure", []string{"check", input}, exitChanges},
+		{"missing messages", []string{"-missing-against", bundlePath, input}, exitChanges},
+		{"unknown flag", []string{"-no-such-flag", input}, exitUsage},
+		{"missing output", []string{input}, exitUsage},
+		{"invalid flag value", []string{"-id-case", "kebab", input, filepath.Join(tempDir, "kebab.go")}, exitUsage},
+		{"parse error", []string{"check", bad}, exitError},
+		{"missing input", []string{filepath.Join(tempDir, "missing.go"), filepath.Join(tempDir, "out.go")}, exitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(tt.args))
+		})
+	}
+}
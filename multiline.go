@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+)
+
+// isGeneratedLocalizeConfig 检查复合字面量是否是工具生成的 LocalizeConfig
+// 生成的节点经 placeAt 定位后左右花括号位于同一位置，手写的字面量不会如此
+func isGeneratedLocalizeConfig(lit *ast.CompositeLit) bool {
+	if lit.Lbrace != lit.Rbrace {
+		return false
+	}
+	switch typ := lit.Type.(type) {
+	case *ast.SelectorExpr:
+		return typ.Sel.Name == "LocalizeConfig"
+	case *ast.Ident:
+		return typ.Name == "LocalizeConfig"
+	}
+	return false
+}
+
+// generatedConfigIndexes 返回工具生成的 LocalizeConfig 在文件全部复合字面量中按遍历顺序的下标
+// 嵌套在另一个生成的调用中的（如 TemplateData 的值）不计入，只拆分最外层的调用
+func generatedConfigIndexes(file *ast.File) map[int]bool {
+	indexes := map[int]bool{}
+	nested := map[*ast.CompositeLit]bool{}
+	i := 0
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		if !nested[lit] && isGeneratedLocalizeConfig(lit) {
+			indexes[i] = true
+			for _, elt := range lit.Elts {
+				ast.Inspect(elt, func(inner ast.Node) bool {
+					if inner, ok := inner.(*ast.CompositeLit); ok {
+						nested[inner] = true
+					}
+					return true
+				})
+			}
+		}
+		i++
+		return true
+	})
+	return indexes
+}
+
+// splitGeneratedConfigs 将打印结果中工具生成的 LocalizeConfig 改为每个字段一行，
+// 如 MessageID 一行、DefaultMessage 一行，便于审查差异；字段之间的注释保持不变
+// printed 是 file 打印的结果，parsed 是其重新解析的语法树，两者中复合字面量的遍历顺序相同
+func splitGeneratedConfigs(file *ast.File, printed []byte, parsed *ast.File, fset *token.FileSet) []byte {
+	indexes := generatedConfigIndexes(file)
+	var lits []*ast.CompositeLit
+	i := 0
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.CompositeLit); ok {
+			if indexes[i] && len(lit.Elts) > 0 {
+				lits = append(lits, lit)
+			}
+			i++
+		}
+		return true
+	})
+
+	offset := func(pos token.Pos) int { return fset.Position(pos).Offset }
+	var out bytes.Buffer
+	// 从文件开头复制，保留 package 之前的许可证、文档注释和构建约束
+	last := 0
+	for _, lit := range lits {
+		out.Write(printed[last : offset(lit.Lbrace)+1])
+		out.WriteString("\n")
+		last = offset(lit.Lbrace) + 1
+		for _, elt := range lit.Elts {
+			// 元素之前是左花括号或上一个元素后的逗号，逗号后换行
+			between := bytes.TrimLeft(printed[last:offset(elt.Pos())], " \t")
+			if bytes.HasPrefix(between, []byte(",")) {
+				out.WriteString(",")
+				between = bytes.TrimLeft(between[1:], " \t")
+				if !bytes.HasPrefix(between, []byte("\n")) {
+					out.WriteString("\n")
+				}
+			}
+			out.Write(between)
+			out.Write(printed[offset(elt.Pos()):offset(elt.End())])
+			last = offset(elt.End())
+		}
+		// 右花括号单独一行时最后一个元素后需要逗号
+		tail := printed[last:offset(lit.Rbrace)]
+		if !bytes.HasPrefix(tail, []byte(",")) {
+			out.WriteString(",")
+		}
+		out.Write(tail)
+		out.WriteString("\n")
+		last = offset(lit.Rbrace)
+	}
+	out.Write(printed[last:])
+	return out.Bytes()
+}
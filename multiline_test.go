@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultilineCalls(t *testing.T) {
+	input := `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func show(string, string) {}
+
+func example(n int) {
+	show("你好", // 问候
+		"世界")
+	_ = fmt.Sprintf("第%d页", n)
+	cfg := &i18n.LocalizeConfig{MessageID: "existing"}
+	_ = cfg
+}`
+
+	output, msgs := transformString(t, input, Options{MultilineCalls: true, ConvertSprintf: true})
+	assert.Len(t, msgs, 3)
+	// MessageID 和 DefaultMessage 各占一行，右花括号单独一行
+	assert.Contains(t, output, "\tshow(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{\n"+
+		"\t\tMessageID:\t\"nh\",\n"+
+		"\t\tDefaultMessage:\t&i18n.Message{ID: \"nh\", Other: \"你好\"},\n"+
+		"\t}),\t// 问候\n")
+	assert.Contains(t, output, "\t\t\tMessageID:\t\"sj\",\n")
+	assert.Contains(t, output, "\t\tTemplateData:\tmap[string]any{\"Arg0\": n},\n\t})\n")
+	// 手写的 LocalizeConfig 保持不变
+	assert.Contains(t, output, `cfg := &i18n.LocalizeConfig{MessageID: "existing"}`)
+
+	// 使用空格缩进时同样拆分
+	output, _ = transformString(t, input, Options{MultilineCalls: true, UseSpaces: true, TabWidth: 4})
+	assert.Contains(t, output, "    show(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{\n        MessageID:      \"nh\",\n")
+
+	// 默认仍输出在一行
+	output, _ = transformString(t, input, Options{})
+	assert.Contains(t, output, `&i18n.LocalizeConfig{MessageID: "nh", DefaultMessage: &i18n.Message{ID: "nh", Other: "你好"}}`)
+}
+
+func TestMultilineCallsNested(t *testing.T) {
+	input := `package main
+
+func example(name string) string {
+	return fmt.Sprintf("你好%s", "世界")
+}`
+
+	output, _ := transformString(t, input, Options{MultilineCalls: true, ConvertSprintf: true})
+	// 嵌套在 TemplateData 中的调用不再拆分
+	assert.Contains(t, output, `"Arg0": i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "sj", DefaultMessage: &i18n.Message{ID: "sj", Other: "世界"}})},`+"\n")
+	assert.Contains(t, output, "return i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{\n\t\tMessageID:\t\"nh\",\n")
+	typeCheck(t, output)
+}
+
+// package 之前的许可证、构建约束和包文档注释原样保留
+func TestMultilineCallsKeepsHeader(t *testing.T) {
+	header := `// Copyright 2024 Example Authors. All rights reserved.
+
+//go:build linux
+
+// Package main 演示程序
+package main
+`
+	output, msgs := transformString(t, header+`
+func example() string {
+	return "你好"
+}`, Options{MultilineCalls: true})
+	assert.Len(t, msgs, 1)
+	assert.True(t, strings.HasPrefix(output, header), output)
+	assert.Contains(t, output, "\t\tMessageID:\t\"nh\",\n")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// errorResultSlot 检查当前节点是否位于可以多接收一个 error 返回值的位置
+// 只支持单个变量的赋值和不带类型的变量声明，如 s := "中文"、s = "中文"、var s = "中文"；
+// 可以时返回在左侧追加 _ 的函数，生成的代码忽略 Localize 返回的 error
+func errorResultSlot(cursor *astutil.Cursor) (addBlank func(), ok bool) {
+	switch parent := cursor.Parent().(type) {
+	case *ast.AssignStmt:
+		if len(parent.Lhs) != 1 || len(parent.Rhs) != 1 || parent.Rhs[0] != cursor.Node() {
+			return nil, false
+		}
+		if parent.Tok != token.ASSIGN && parent.Tok != token.DEFINE {
+			return nil, false
+		}
+		return func() {
+			parent.Lhs = append(parent.Lhs, &ast.Ident{Name: "_", NamePos: parent.Lhs[0].End()})
+		}, true
+	case *ast.ValueSpec:
+		if len(parent.Names) != 1 || len(parent.Values) != 1 || parent.Type != nil || parent.Values[0] != cursor.Node() {
+			return nil, false
+		}
+		return func() {
+			parent.Names = append(parent.Names, &ast.Ident{Name: "_", NamePos: parent.Names[0].End()})
+		}, true
+	}
+	return nil, false
+}
+
+// dropErrorResult 撤销 errorResultSlot 追加的 _，用于还原 -no-panic 生成的 Localize 调用
+// 当前节点不是以 _ 接收 error 的单个赋值或变量声明时返回 false，不做修改
+func dropErrorResult(cursor *astutil.Cursor) bool {
+	switch parent := cursor.Parent().(type) {
+	case *ast.AssignStmt:
+		if len(parent.Lhs) != 2 || len(parent.Rhs) != 1 || parent.Rhs[0] != cursor.Node() || !isBlank(parent.Lhs[1]) {
+			return false
+		}
+		parent.Lhs = parent.Lhs[:1]
+		return true
+	case *ast.ValueSpec:
+		if len(parent.Names) != 2 || len(parent.Values) != 1 || parent.Values[0] != cursor.Node() || !isBlank(parent.Names[1]) {
+			return false
+		}
+		parent.Names = parent.Names[:1]
+		return true
+	}
+	return false
+}
+
+// isBlank 检查表达式是否为空白标识符 _
+func isBlank(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "_"
+}
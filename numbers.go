@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"regexp"
+)
+
+// 包含数字的中文字符串的处理方式
+const (
+	embeddedNumbersWarn = "warn"
+	embeddedNumbersSkip = "skip"
+)
+
+// embeddedNumber 匹配与中文相邻的数字，如 第1页、共 10 条
+var embeddedNumber = regexp.MustCompile(`\p{Han}\s*\d|\d\s*\p{Han}`)
+
+// validateEmbeddedNumbers 检查包含数字的中文字符串的处理方式是否合法
+func validateEmbeddedNumbers(mode string) error {
+	switch mode {
+	case "", embeddedNumbersWarn, embeddedNumbersSkip:
+		return nil
+	}
+	return fmt.Errorf("不支持的处理方式 %q，可选 %s、%s", mode, embeddedNumbersWarn, embeddedNumbersSkip)
+}
+
+// hasEmbeddedNumber 检查文本中是否有与中文相邻的数字
+// 这类数字通常是运行时的值，直接替换会把具体的数字写进翻译，更适合改为模板消息
+func hasEmbeddedNumber(text string) bool {
+	return embeddedNumber.MatchString(text)
+}
+
+// numberWarning 一个包含数字的中文字符串
+type numberWarning struct {
+	Position token.Position
+	Text     string
+}
+
+// findNumberWarnings 找出包含数字的中文字符串，包括 -embedded-numbers=skip 时被跳过的字符串
+func findNumberWarnings(file *ast.File, fset *token.FileSet, opts Options) []numberWarning {
+	opts.EmbeddedNumbers = ""
+	var warnings []numberWarning
+	for _, c := range collectCandidates(file, fset, opts) {
+		if hasEmbeddedNumber(c.Other) {
+			warnings = append(warnings, numberWarning{Position: c.Position, Text: c.Other})
+		}
+	}
+	return warnings
+}
+
+// printNumberWarnings 输出包含数字的中文字符串的提示，同时包含数量的字符串提示使用复数形式
+func printNumberWarnings(w io.Writer, warnings []numberWarning, opts Options) {
+	for _, warning := range warnings {
+		message := fmt.Sprintf("%q 包含数字，可能需要改为模板消息，如 fmt.Sprintf 配合 -convert-sprintf", warning.Text)
+		if opts.EmbeddedNumbers == embeddedNumbersSkip {
+			message = fmt.Sprintf("%q 包含数字，已跳过，可改为模板消息后再替换", warning.Text)
+		}
+		if needsPlural(warning.Text) {
+			message += "，并使用复数形式"
+		}
+		fmt.Fprintf(w, "警告: %s: %s\n", warning.Position, message)
+		opts.logDiagnostic(newDiagnostic(severityWarning, codeEmbeddedNumber, warning.Position, message))
+	}
+}
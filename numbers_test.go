@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasEmbeddedNumber(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"第1页", true},
+		{"共10条", true},
+		{"剩余 3 次", true},
+		{"2024年", true},
+		{"你好世界", false},
+		{"第%d页", false},
+		{"删除了%5d个文件", false},
+		{"version 1.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hasEmbeddedNumber(tt.text))
+		})
+	}
+}
+
+func TestEmbeddedNumbers(t *testing.T) {
+	input := `package main
+
+func example() {
+	a := "第1页"
+	b := "你好世界"
+	c := "共10条"
+}`
+
+	// warn 时照常替换
+	_, msgs := transformString(t, input, Options{EmbeddedNumbers: embeddedNumbersWarn})
+	assert.Len(t, msgs, 3)
+
+	output, msgs := transformString(t, input, Options{EmbeddedNumbers: embeddedNumbersSkip})
+	assert.Equal(t, []Message{{ID: "nhsj", Other: "你好世界"}}, msgs)
+	assert.Contains(t, output, `a := "第1页"`)
+	assert.Contains(t, output, `c := "共10条"`)
+
+	// 被跳过的字符串同样给出提示
+	fset, file := parseSource(t, input)
+	opts := Options{EmbeddedNumbers: embeddedNumbersSkip}
+	warnings := findNumberWarnings(file, fset, opts)
+	assert.Len(t, warnings, 2)
+
+	var buf bytes.Buffer
+	printNumberWarnings(&buf, warnings, opts)
+	assert.Contains(t, buf.String(), `4:7: "第1页" 包含数字，已跳过，可改为模板消息后再替换`+"\n")
+	assert.Contains(t, buf.String(), `6:7: "共10条" 包含数字，已跳过，可改为模板消息后再替换，并使用复数形式`)
+
+	buf.Reset()
+	printNumberWarnings(&buf, warnings, Options{EmbeddedNumbers: embeddedNumbersWarn})
+	assert.Contains(t, buf.String(), `"第1页" 包含数字，可能需要改为模板消息`)
+
+	assert.Error(t, validateEmbeddedNumbers("ignore"))
+}
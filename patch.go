@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// patchContext 补丁中每处修改前后保留的上下文行数，与 git diff 相同
+const patchContext = 3
+
+// writeUnifiedDiff 将 path 从 before 到 after 的修改以统一格式写入 w，可以用 git apply 或 patch -p1 应用
+// 内容相同时不写入，返回 false
+func writeUnifiedDiff(w io.Writer, path string, before, after []byte) bool {
+	a, b := splitLines(string(before)), splitLines(string(after))
+	groups := difflib.NewMatcher(a, b).GetGroupedOpCodes(patchContext)
+	if len(groups) == 0 {
+		return false
+	}
+
+	fmt.Fprintf(w, "--- a/%s\n", path)
+	fmt.Fprintf(w, "+++ b/%s\n", path)
+	for _, group := range groups {
+		first, last := group[0], group[len(group)-1]
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(first.I1, last.I2), hunkRange(first.J1, last.J2))
+		for _, op := range group {
+			if op.Tag == 'e' {
+				writePatchLines(w, ' ', a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == 'r' || op.Tag == 'd' {
+				writePatchLines(w, '-', a[op.I1:op.I2])
+			}
+			if op.Tag == 'r' || op.Tag == 'i' {
+				writePatchLines(w, '+', b[op.J1:op.J2])
+			}
+		}
+	}
+	return true
+}
+
+// writePatchLines 写入补丁中的若干行，没有换行符的最后一行按统一格式追加说明
+func writePatchLines(w io.Writer, prefix byte, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintf(w, "%c%s", prefix, line)
+		if !strings.HasSuffix(line, "\n") {
+			fmt.Fprint(w, "\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange 将从 0 开始的半开区间 [start, stop) 格式化为统一格式中的 起始行,行数
+// 只有一行时省略行数，没有行时起始行为前一行
+func hunkRange(start, stop int) string {
+	length := stop - start
+	switch length {
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
+
+// splitLines 按行切分文本，每行保留换行符；文本不以换行符结尾时最后一行没有换行符
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// patchPath 返回补丁中使用的文件路径：尽量使用相对于当前目录的路径，分隔符统一为 /
+func patchPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchOut(t *testing.T) {
+	tempDir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("获取当前目录失败: %v", err)
+	}
+	// 补丁中使用相对于当前目录的路径，在临时目录中运行才能用 git apply 应用
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("切换目录失败: %v", err)
+	}
+	defer os.Chdir(wd)
+
+	// 最后一行没有换行符时补丁也要能应用
+	original := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"你好世界\")\n}"
+	clean := "package main\n\nfunc add(a, b int) int {\n\treturn a + b\n}\n"
+	writeTestFile(t, tempDir, "a.go", original)
+	writeTestFile(t, tempDir, filepath.Join("sub", "b.go"), clean)
+
+	assert.Equal(t, exitChanges, run([]string{"-patch-out", "i18n.patch", "a.go", filepath.Join("sub", "b.go")}))
+
+	// 原文件不被修改，没有修改的文件不出现在补丁中
+	data, err := os.ReadFile("a.go")
+	assert.NoError(t, err)
+	assert.Equal(t, original, string(data))
+	patch, err := os.ReadFile("i18n.patch")
+	assert.NoError(t, err)
+	assert.Contains(t, string(patch), "--- a/a.go\n+++ b/a.go\n@@ ")
+	assert.NotContains(t, string(patch), "b.go")
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("没有 git，跳过应用补丁")
+	}
+	for _, args := range [][]string{{"apply", "--check", "i18n.patch"}, {"apply", "i18n.patch"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tempDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v 失败: %v\n%s", args, err, out)
+		}
+	}
+	expected, _, err := TransformSource([]byte(original), Options{})
+	assert.NoError(t, err)
+	data, err = os.ReadFile("a.go")
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+}
+
+func TestPatchOutConflicts(t *testing.T) {
+	tempDir := t.TempDir()
+	path := writeTestFile(t, tempDir, "a.go", "package main\n\nvar s = \"你好\"\n")
+	patch := filepath.Join(tempDir, "i18n.patch")
+
+	assert.Equal(t, exitUsage, run([]string{"-patch-out", patch, "-w", path}))
+	assert.Equal(t, exitUsage, run([]string{"-patch-out", patch, "-dry-run", path}))
+	_, err := os.Stat(patch)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestHunkRange(t *testing.T) {
+	tests := []struct {
+		start, stop int
+		expected    string
+	}{
+		{0, 1, "1"},
+		{0, 3, "1,3"},
+		{4, 9, "5,5"},
+		{0, 0, "0,0"},
+		{7, 7, "7,0"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, hunkRange(tt.start, tt.stop))
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	assert.Equal(t, []string{"a\n", "b\n"}, splitLines("a\nb\n"))
+	assert.Equal(t, []string{"a\n", "b"}, splitLines("a\nb"))
+	assert.Equal(t, []string{}, splitLines(""))
+}
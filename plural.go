@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"regexp"
+)
+
+// pluralHint 匹配可能需要复数形式的文本：数字或模板字段后跟量词，或整数格式化占位符
+var pluralHint = regexp.MustCompile(`(\d|\}\})\s*[个条次项]|%[-+# 0]*\d*d`)
+
+// pluralWarning 一个可能需要复数形式的字符串
+type pluralWarning struct {
+	Position token.Position
+	Text     string
+}
+
+// findPluralWarnings 找出待替换字符串中可能需要复数形式的字符串，只做提示，不修改语法树
+func findPluralWarnings(file *ast.File, fset *token.FileSet, opts Options) []pluralWarning {
+	var warnings []pluralWarning
+	for _, c := range collectCandidates(file, fset, opts) {
+		if needsPlural(c.Other) {
+			warnings = append(warnings, pluralWarning{Position: c.Position, Text: c.Other})
+		}
+	}
+	return warnings
+}
+
+// needsPlural 检查文本是否包含数量，翻译时可能需要区分单复数
+func needsPlural(text string) bool {
+	return pluralHint.MatchString(text)
+}
+
+// printPluralWarnings 输出复数形式提示
+func printPluralWarnings(w io.Writer, warnings []pluralWarning, opts Options) {
+	for _, warning := range warnings {
+		message := fmt.Sprintf("%q 包含数量，可能需要复数形式", warning.Text)
+		fmt.Fprintf(w, "警告: %s: %s\n", warning.Position, message)
+		opts.logDiagnostic(newDiagnostic(severityWarning, codePlural, warning.Position, message))
+	}
+}
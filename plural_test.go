@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsPlural(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{"共3条记录", true},
+		{"剩余 10 次机会", true},
+		{"已选择%d项", true},
+		{"删除了%5d个文件", true},
+		{"第1页", false},
+		{"你好世界", false},
+		{"用户%s登录", false},
+		{"项目名称", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, needsPlural(tt.text))
+		})
+	}
+}
+
+func TestFindPluralWarnings(t *testing.T) {
+	fset, file := parseSource(t, `package main
+
+func example() {
+	a := "共3条记录"
+	b := "你好世界"
+	c := fmt.Sprintf("已选择%d项", n)
+}`)
+
+	warnings := findPluralWarnings(file, fset, Options{})
+	assert.Len(t, warnings, 2)
+	assert.Equal(t, 4, warnings[0].Position.Line)
+	assert.Equal(t, "已选择%d项", warnings[1].Text)
+
+	var buf bytes.Buffer
+	printPluralWarnings(&buf, warnings, Options{})
+	assert.Contains(t, buf.String(), `4:7: "共3条记录" 包含数量，可能需要复数形式`)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 支持的消息文件格式
+const (
+	bundleFormatTOML = "toml"
+	bundleFormatPO   = "po"
+)
+
+// validateBundleFormat 检查消息文件格式是否合法
+func validateBundleFormat(format string) error {
+	switch format {
+	case "", bundleFormatTOML, bundleFormatPO:
+		return nil
+	}
+	return fmt.Errorf("不支持的消息文件格式 %q，可选 %s、%s", format, bundleFormatTOML, bundleFormatPO)
+}
+
+// poEntry gettext 模板中的一条消息
+type poEntry struct {
+	ID         string
+	Other      string
+	References []string
+}
+
+// collectPOEntries 按ID合并消息，同一ID的多处引用记录在同一条消息中，结果按ID排序
+func collectPOEntries(msgs []Message) []poEntry {
+	index := map[string]int{}
+	var entries []poEntry
+	for _, msg := range msgs {
+		// 只有ID的消息没有原文可写入模板
+		if msg.referenceOnly() {
+			continue
+		}
+		i, ok := index[msg.ID]
+		if !ok {
+			i = len(entries)
+			index[msg.ID] = i
+			// 原文作为说明时 Other 只是占位文本
+			other := msg.Other
+			if msg.Description != "" {
+				other = msg.Description
+			}
+			entries = append(entries, poEntry{ID: msg.ID, Other: other})
+		}
+		if msg.Position.Filename != "" {
+			ref := fmt.Sprintf("%s:%d", msg.Position.Filename, msg.Position.Line)
+			entries[i].References = append(entries[i].References, ref)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries
+}
+
+// encodePOTemplate 将消息编码为 gettext 模板（.pot）
+// msgid 为消息ID，原文写在 #. 注释中，#: 注释记录源码位置，msgstr 留空供翻译填写
+func encodePOTemplate(entries []poEntry, lang string) []byte {
+	var b strings.Builder
+	b.WriteString("msgid \"\"\n")
+	b.WriteString("msgstr \"\"\n")
+	b.WriteString("\"Content-Type: text/plain; charset=UTF-8\\n\"\n")
+	b.WriteString("\"Content-Transfer-Encoding: 8bit\\n\"\n")
+	b.WriteString("\"X-Generator: str2go-i18n\\n\"\n")
+	fmt.Fprintf(&b, "\"X-Source-Language: %s\\n\"\n", lang)
+
+	for _, entry := range entries {
+		b.WriteString("\n")
+		for _, line := range strings.Split(entry.Other, "\n") {
+			fmt.Fprintf(&b, "#. %s\n", line)
+		}
+		if len(entry.References) > 0 {
+			fmt.Fprintf(&b, "#: %s\n", strings.Join(entry.References, " "))
+		}
+		fmt.Fprintf(&b, "msgid %s\n", poQuote(entry.ID))
+		b.WriteString("msgstr \"\"\n")
+	}
+	return []byte(b.String())
+}
+
+// poQuote 按 PO 文件的规则给字符串加上引号并转义
+func poQuote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// writePOTemplate 将提取的消息写入 path 指向的 gettext 模板
+// 模板总是根据代码重新生成，不与已有文件合并，changed 表示模板内容有变化
+func writePOTemplate(path string, msgs []Message, opts Options) (changed bool, err error) {
+	entries := collectPOEntries(msgs)
+	data := encodePOTemplate(entries, opts.sourceLanguage())
+	// 说明来源的注释中有生成时间，只比较其后的模板内容
+	existing, err := os.ReadFile(path)
+	changed = err != nil || !bytes.HasSuffix(existing, data)
+	if opts.BundleHeader {
+		data = append([]byte(bundleHeader(time.Now(), toolVersion(), opts.sourceLanguage())), data...)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+	printPOSummary(os.Stdout, path, entries)
+	return changed, nil
+}
+
+// printPOSummary 输出 gettext 模板中的消息数量
+func printPOSummary(w io.Writer, path string, entries []poEntry) {
+	fmt.Fprintf(w, "消息模板 %s: 共 %d 条消息\n", path, len(entries))
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodePOTemplate(t *testing.T) {
+	msgs := []Message{
+		{ID: "nhsj", Other: "你好世界", Position: token.Position{Filename: "b.go", Line: 7}},
+		{ID: "czcg", Other: "操作\"成功\"\n完成", Position: token.Position{Filename: "a.go", Line: 3}},
+		{ID: "nhsj", Other: "你好世界", Position: token.Position{Filename: "a.go", Line: 9}},
+	}
+
+	blocks := strings.Split(strings.TrimSuffix(string(encodePOTemplate(collectPOEntries(msgs), defaultSourceLanguage)), "\n"), "\n\n")
+	if !assert.Len(t, blocks, 3) {
+		return
+	}
+
+	// 第一条为头部，msgid 为空，声明编码和源语言
+	header := strings.Split(blocks[0], "\n")
+	assert.Equal(t, `msgid ""`, header[0])
+	assert.Equal(t, `msgstr ""`, header[1])
+	assert.Contains(t, blocks[0], `"Content-Type: text/plain; charset=UTF-8\n"`)
+	assert.Contains(t, blocks[0], `"X-Source-Language: zh-Hans\n"`)
+
+	// 消息按ID排序，每条消息依次为原文注释、位置注释、msgid 和留空的 msgstr
+	assert.Equal(t, []string{
+		`#. 操作"成功"`,
+		`#. 完成`,
+		`#: a.go:3`,
+		`msgid "czcg"`,
+		`msgstr ""`,
+	}, strings.Split(blocks[1], "\n"))
+	assert.Equal(t, []string{
+		`#. 你好世界`,
+		`#: b.go:7 a.go:9`,
+		`msgid "nhsj"`,
+		`msgstr ""`,
+	}, strings.Split(blocks[2], "\n"))
+}
+
+func TestPOQuote(t *testing.T) {
+	assert.Equal(t, `"你好"`, poQuote("你好"))
+	assert.Equal(t, `"a\"b\\c\nd\te"`, poQuote("a\"b\\c\nd\te"))
+}
+
+func TestBundleFormatPO(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	potPath := filepath.Join(tempDir, "messages.pot")
+
+	assert.Equal(t, exitChanges, run([]string{"extract", "-format", "po", "-bundle-out", potPath, input}))
+	data, err := os.ReadFile(potPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "#. 你好世界\n#: "+input+":3\nmsgid \"nhsj\"\nmsgstr \"\"\n")
+
+	assert.NoError(t, validateBundleFormat(bundleFormatTOML))
+	assert.Error(t, validateBundleFormat("xliff"))
+	assert.Equal(t, exitUsage, run([]string{"extract", "-format", "xliff", "-bundle-out", potPath, input}))
+}
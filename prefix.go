@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// idPrefixSeparator 连接目录前缀和自动生成的消息ID，如 auth_nhsj
+const idPrefixSeparator = "_"
+
+// loadIDPrefixes 读取 -id-prefix-map 指定的 目录→前缀 映射文件，格式为 TOML，每行一个目录：
+//
+//	"services/auth" = "auth"
+//	"services/billing" = "billing"
+//
+// 相对路径相对于映射文件所在的目录，返回的目录都是绝对路径；前缀需要是合法的消息ID
+func loadIDPrefixes(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %w", path, err)
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make(map[string]string, len(raw))
+	for dir, prefix := range raw {
+		if err := validateMessageID(prefix); err != nil {
+			return nil, fmt.Errorf("%s: 目录 %s 的前缀不合法: %w", path, dir, err)
+		}
+		dir = filepath.FromSlash(dir)
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(base, dir)
+		}
+		prefixes[filepath.Clean(dir)] = prefix
+	}
+	return prefixes, nil
+}
+
+// idPrefix 返回 path 所在目录在 o.IDPrefixes 中的前缀，多个目录都包含该文件时使用最深的目录，没有时返回空字符串
+func (o Options) idPrefix(path string) string {
+	if len(o.IDPrefixes) == 0 || path == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	matched, prefix := "", ""
+	for dir, p := range o.IDPrefixes {
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+		if strings.HasPrefix(abs, dir+string(filepath.Separator)) && len(dir) > len(matched) {
+			matched, prefix = dir, p
+		}
+	}
+	return prefix
+}
+
+// applyIDPrefix 为 path 中的文件返回加上所在目录前缀的 原文→消息ID 映射，没有前缀时返回 ids
+// annotated 中的原文使用注释指定的ID，不加前缀
+func applyIDPrefix(ids map[string]string, annotated map[string]bool, path string, opts Options) map[string]string {
+	prefix := opts.idPrefix(path)
+	if prefix == "" {
+		return ids
+	}
+	prefixed := make(map[string]string, len(ids))
+	for other, id := range ids {
+		if !annotated[other] {
+			id = prefix + idPrefixSeparator + id
+		}
+		prefixed[other] = id
+	}
+	return prefixed
+}
+
+// annotatedTexts 返回通过 //i18n:id= 注释指定了ID的原文，已有调用预留的ID不计入
+func annotatedTexts(cands []candidate) map[string]bool {
+	annotated := map[string]bool{}
+	for _, c := range cands {
+		if c.AnnotatedID != "" && !c.Existing {
+			annotated[c.Other] = true
+		}
+	}
+	return annotated
+}
+
+// prefixedCollision 加上目录前缀后被多个不同原文使用的ID
+type prefixedCollision struct {
+	ID string
+	// Sources 使用该ID的各个原文第一次出现的候选，按出现顺序排列
+	Sources []candidate
+}
+
+// findPrefixedCollisions 找出加上目录前缀后不同原文得到的相同ID，如注释指定的ID恰好与其他目录加了前缀的ID相同
+// fileIDs 与 fileCands 一一对应，为每个文件使用的映射；结果按第二个原文出现的顺序排列
+func findPrefixedCollisions(fileCands [][]candidate, fileIDs []map[string]string, opts Options) []prefixedCollision {
+	index := map[string]int{}
+	var groups []prefixedCollision
+	var order []int
+	for i, cands := range fileCands {
+		for _, c := range cands {
+			id := fileIDs[i][c.Other]
+			if c.AnnotatedID != "" {
+				id = c.AnnotatedID
+			}
+			g, ok := index[id]
+			if !ok {
+				index[id] = len(groups)
+				groups = append(groups, prefixedCollision{ID: id, Sources: []candidate{c}})
+				continue
+			}
+			known := false
+			for _, source := range groups[g].Sources {
+				if textKey(source.Other, opts) == textKey(c.Other, opts) {
+					known = true
+					break
+				}
+			}
+			if !known {
+				groups[g].Sources = append(groups[g].Sources, c)
+				if len(groups[g].Sources) == 2 {
+					order = append(order, g)
+				}
+			}
+		}
+	}
+	collisions := make([]prefixedCollision, 0, len(order))
+	for _, g := range order {
+		collisions = append(collisions, groups[g])
+	}
+	return collisions
+}
+
+// checkPrefixedIDs 检查加上目录前缀后不同原文是否得到了相同的ID，有冲突时返回最先出现的 *CollisionError
+func checkPrefixedIDs(fileCands [][]candidate, fileIDs []map[string]string, opts Options) error {
+	collisions := findPrefixedCollisions(fileCands, fileIDs, opts)
+	if len(collisions) == 0 {
+		return nil
+	}
+	first, second := collisions[0].Sources[0], collisions[0].Sources[1]
+	return &CollisionError{
+		ID:     collisions[0].ID,
+		First:  CollisionSource{Text: first.Other, Position: first.Position},
+		Second: CollisionSource{Text: second.Other, Position: second.Position},
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadIDPrefixes(t *testing.T) {
+	root := t.TempDir()
+	path := writeTestFile(t, root, "config/prefixes.toml", `"services/auth" = "auth"
+"/srv/billing" = "billing"
+`)
+
+	// 相对路径相对于映射文件所在的目录
+	prefixes, err := loadIDPrefixes(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		filepath.Join(root, "config", "services", "auth"): "auth",
+		filepath.FromSlash("/srv/billing"):                "billing",
+	}, prefixes)
+
+	invalid := writeTestFile(t, root, "invalid.toml", `"services/auth" = "认证"`)
+	_, err = loadIDPrefixes(invalid)
+	assert.ErrorContains(t, err, "前缀不合法")
+
+	malformed := writeTestFile(t, root, "malformed.toml", `services/auth = auth`)
+	_, err = loadIDPrefixes(malformed)
+	assert.Error(t, err)
+
+	_, err = loadIDPrefixes(filepath.Join(root, "missing.toml"))
+	assert.Error(t, err)
+}
+
+func TestIDPrefix(t *testing.T) {
+	opts := Options{IDPrefixes: map[string]string{
+		filepath.FromSlash("/repo/services/auth"):          "auth",
+		filepath.FromSlash("/repo/services/auth/internal"): "authint",
+		filepath.FromSlash("/repo/services"):               "svc",
+	}}
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/repo/services/auth/login.go", "auth"},
+		{"/repo/services/auth/handler/login.go", "auth"},
+		// 使用最深的目录
+		{"/repo/services/auth/internal/token.go", "authint"},
+		{"/repo/services/billing/pay.go", "svc"},
+		// 目录名只是前缀相同时不匹配
+		{"/repo/services/authz/check.go", "svc"},
+		{"/repo/cmd/main.go", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, opts.idPrefix(filepath.FromSlash(tt.path)))
+		})
+	}
+	assert.Empty(t, Options{}.idPrefix("/repo/services/auth/login.go"))
+}
+
+func TestIDPrefixMap(t *testing.T) {
+	root := t.TempDir()
+	mapping := writeTestFile(t, root, "prefixes.toml", `"services/auth" = "auth"
+"services/billing" = "billing"
+`)
+	auth := writeTestFile(t, root, "services/auth/login.go", `package auth
+
+var greeting = "你好世界"
+var custom = "登录成功" //i18n:id=login_ok
+`)
+	billing := writeTestFile(t, root, "services/billing/pay.go", `package billing
+
+var greeting = "你好世界"
+var paid = "支付成功"
+`)
+	other := writeTestFile(t, root, "cmd/main.go", `package main
+
+var greeting = "你好世界"
+`)
+
+	// 不同目录下的同一原文使用不同前缀的ID，注释指定的ID和没有对应目录的文件不加前缀
+	fset := token.NewFileSet()
+	files := parseTestFiles(t, fset, auth, billing, other)
+	prefixes, err := loadIDPrefixes(mapping)
+	assert.NoError(t, err)
+	msgs, err := transformFiles(files, fset, Options{IDPrefixes: prefixes})
+	assert.NoError(t, err)
+	assert.Equal(t, []Message{
+		{ID: "auth_nhsj", Other: "你好世界"},
+		{ID: "login_ok", Other: "登录成功"},
+		{ID: "billing_nhsj", Other: "你好世界"},
+		{ID: "billing_zfcg", Other: "支付成功"},
+		{ID: "nhsj", Other: "你好世界"},
+	}, stripPositions(msgs))
+
+	// 命令行中写入源码和消息文件
+	bundlePath := filepath.Join(root, "active.zh.toml")
+	assert.Equal(t, exitChanges, run([]string{"-w", "-id-prefix-map", mapping, "-bundle-out", bundlePath, auth, billing, other}))
+	data, err := os.ReadFile(billing)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `MessageID: "billing_zfcg"`)
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	for _, id := range []string{"auth_nhsj", "billing_nhsj", "billing_zfcg", "login_ok", "nhsj"} {
+		assert.Contains(t, b, id)
+	}
+
+	assert.Equal(t, exitError, run([]string{"-w", "-id-prefix-map", filepath.Join(root, "missing.toml"), other}))
+}
+
+func TestIDPrefixCollision(t *testing.T) {
+	root := t.TempDir()
+	auth := writeTestFile(t, root, "auth/login.go", `package auth
+
+var greeting = "你好"
+`)
+	other := writeTestFile(t, root, "cmd/main.go", `package main
+
+var other = "其他" //i18n:id=auth_nh
+`)
+
+	// 注释指定的ID与加上前缀的ID相同时报告冲突
+	fset := token.NewFileSet()
+	files := parseTestFiles(t, fset, auth, other)
+	_, err := transformFiles(files, fset, Options{IDPrefixes: map[string]string{filepath.Join(root, "auth"): "auth"}})
+	var collision *CollisionError
+	if assert.ErrorAs(t, err, &collision) {
+		assert.Equal(t, "auth_nh", collision.ID)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// previewIDs 按当前选项跨文件计算待替换字符串的消息ID并以表格输出到 w，不修改文件
+// 每个字符串一行，依次为位置、最终的消息ID和原文；不同原文生成了相同的ID时在最后一列标出冲突，
+// 最终的消息ID已按 -unique-suffix 处理并加上 -id-prefix-map 的前缀，为 none 时保留冲突的ID，实际转换会报错；
+// 加上前缀后与其他原文的ID相同时转换同样会报错，这些字符串也标为冲突
+func previewIDs(w io.Writer, files []string, opts Options) (collisions int, err error) {
+	fset := token.NewFileSet()
+	var cands []candidate
+	var parsed []*ast.File
+	for _, path := range files {
+		file, skip, err := parseInputFile(fset, path, opts)
+		if err != nil {
+			return 0, err
+		}
+		if skip != "" {
+			fmt.Fprintf(w, "警告: 跳过 %s: %s\n", path, skip)
+			opts.logDiagnostic(newDiagnostic(severityWarning, codeSkippedFile, token.Position{Filename: path}, "跳过: "+skip))
+			continue
+		}
+		cands = append(cands, collectCandidates(file, fset, opts)...)
+		parsed = append(parsed, file)
+	}
+
+	// 已有调用使用的ID参与冲突检查，但不单独列出
+	planned := append(append([]candidate(nil), cands...), existingCandidates(parsed, fset, opts)...)
+	base, _, err := baseMessageIDs(planned, opts)
+	if err != nil {
+		return 0, err
+	}
+	ids := base
+	if opts.UniqueSuffix != uniqueSuffixNone {
+		if ids, err = planMessageIDs(planned, opts); err != nil {
+			return 0, err
+		}
+	}
+
+	// 按处理冲突前的ID归并文本，同一ID下有多个文本即为冲突
+	texts := map[string]map[string]bool{}
+	for other, id := range base {
+		if texts[id] == nil {
+			texts[id] = map[string]bool{}
+		}
+		texts[id][textKey(other, opts)] = true
+	}
+	var collided []string
+	for id, keys := range texts {
+		if len(keys) > 1 {
+			collided = append(collided, id)
+		}
+	}
+	sort.Strings(collided)
+
+	// 最终的ID按文件所在目录加上前缀
+	annotated := annotatedTexts(cands)
+	fileIDs := map[string]map[string]string{}
+	var fileCands [][]candidate
+	var fileMaps []map[string]string
+	for _, c := range cands {
+		name := c.Position.Filename
+		if _, ok := fileIDs[name]; !ok {
+			fileIDs[name] = applyIDPrefix(ids, annotated, name, opts)
+			fileCands = append(fileCands, nil)
+			fileMaps = append(fileMaps, fileIDs[name])
+		}
+		// 同一文件的候选是连续的
+		fileCands[len(fileCands)-1] = append(fileCands[len(fileCands)-1], c)
+	}
+
+	// 加上前缀后不同原文得到相同的ID时实际转换会报错，同样标出
+	prefixed := map[string]int{}
+	if len(opts.IDPrefixes) > 0 {
+		for _, collision := range findPrefixedCollisions(fileCands, fileMaps, opts) {
+			prefixed[collision.ID] = len(collision.Sources)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "位置\t消息ID\t原文\t冲突")
+	for _, c := range cands {
+		final := fileIDs[c.Position.Filename][c.Other]
+		if c.AnnotatedID != "" {
+			final = c.AnnotatedID
+		}
+		note := ""
+		if id := base[c.Other]; len(texts[id]) > 1 {
+			note = fmt.Sprintf("冲突: %d 个不同原文生成了 %s", len(texts[id]), id)
+		} else if n := prefixed[final]; n > 1 {
+			note = fmt.Sprintf("冲突: 加上目录前缀后 %d 个不同原文使用了 %s", n, final)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%q\t%s\n", c.Position, final, c.Other, note)
+	}
+	if err := tw.Flush(); err != nil {
+		return 0, err
+	}
+	collisions = len(collided) + len(prefixed)
+	fmt.Fprintf(w, "共 %d 个字符串，%d 个ID存在冲突\n", len(cands), collisions)
+	return collisions, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewIDs(t *testing.T) {
+	tempDir := t.TempDir()
+	a := writeTestFile(t, tempDir, "a.go", `package main
+
+var greeting = "你好世界"
+var success = "成功"
+`)
+	b := writeTestFile(t, tempDir, "b.go", `package main
+
+var other = "你还是家"
+var again = "你好世界"
+`)
+
+	var buf bytes.Buffer
+	collisions, err := previewIDs(&buf, []string{a, b}, Options{UniqueSuffix: uniqueSuffixCounter})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, collisions)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 6) {
+		return
+	}
+	assert.Equal(t, []string{"位置", "消息ID", "原文", "冲突"}, strings.Fields(lines[0]))
+	// 每个字符串一行，ID按跨文件的规则生成，冲突的字符串都会标出
+	assert.Equal(t, []string{a + ":3:16", "nhsj", `"你好世界"`, "冲突:", "2", "个不同原文生成了", "nhsj"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{a + ":4:15", "cg", `"成功"`}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{b + ":3:13", "nhsj_1", `"你还是家"`, "冲突:", "2", "个不同原文生成了", "nhsj"}, strings.Fields(lines[3]))
+	assert.Equal(t, []string{b + ":4:13", "nhsj", `"你好世界"`, "冲突:", "2", "个不同原文生成了", "nhsj"}, strings.Fields(lines[4]))
+	assert.Equal(t, "共 4 个字符串，1 个ID存在冲突", lines[5])
+
+	// -unique-suffix none 时保留冲突的ID，不报错
+	buf.Reset()
+	collisions, err = previewIDs(&buf, []string{a, b}, Options{UniqueSuffix: uniqueSuffixNone})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, collisions)
+	assert.NotContains(t, buf.String(), "nhsj_")
+
+	// 子命令只预览，不修改文件
+	assert.Equal(t, 0, run([]string{"preview-ids", a, b}))
+	data, err := os.ReadFile(a)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "i18n")
+}
+
+// 加上目录前缀后与其他原文的ID相同时也标为冲突，与实际转换的结果一致
+func TestPreviewIDsPrefixCollision(t *testing.T) {
+	root := t.TempDir()
+	auth := writeTestFile(t, root, "auth/login.go", `package auth
+
+var greeting = "你好"
+`)
+	other := writeTestFile(t, root, "cmd/main.go", `package main
+
+var other = "其他" //i18n:id=auth_nh
+var success = "成功"
+`)
+	opts := Options{IDPrefixes: map[string]string{filepath.Join(root, "auth"): "auth"}}
+
+	var buf bytes.Buffer
+	collisions, err := previewIDs(&buf, []string{auth, other}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, collisions)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 5) {
+		return
+	}
+	assert.Equal(t, []string{auth + ":3:16", "auth_nh", `"你好"`, "冲突:", "加上目录前缀后", "2", "个不同原文使用了", "auth_nh"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{other + ":3:13", "auth_nh", `"其他"`, "冲突:", "加上目录前缀后", "2", "个不同原文使用了", "auth_nh"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{other + ":4:15", "cg", `"成功"`}, strings.Fields(lines[3]))
+	assert.Equal(t, "共 3 个字符串，1 个ID存在冲突", lines[4])
+
+	// 实际转换时报告同一个冲突
+	fset := token.NewFileSet()
+	_, err = transformFiles(parseTestFiles(t, fset, auth, other), fset, opts)
+	var collision *CollisionError
+	if assert.ErrorAs(t, err, &collision) {
+		assert.Equal(t, "auth_nh", collision.ID)
+	}
+}
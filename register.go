@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"sort"
+)
+
+// generateRegisterFile 生成在代码中注册消息的 Go 源文件
+// 生成的文件提供 RegisterMessages(bundle) 函数，通过 bundle.AddMessages 注册全部消息，
+// 适用于不使用 TOML 消息文件的项目。消息按ID去重并排序以保证输出稳定，只有ID的消息没有原文，不注册
+func generateRegisterFile(pkg, lang string, msgs []Message) ([]byte, error) {
+	seen := map[string]bool{}
+	var unique []Message
+	for _, msg := range msgs {
+		if seen[msg.ID] || msg.referenceOnly() {
+			continue
+		}
+		seen[msg.ID] = true
+		unique = append(unique, msg)
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i].ID < unique[j].ID })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by str2go-i18n. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import (\n\t%q\n\t%q\n)\n\n", i18nImportPath, "golang.org/x/text/language")
+	buf.WriteString("// RegisterMessages 将提取的消息注册到 bundle\n")
+	buf.WriteString("func RegisterMessages(bundle *i18n.Bundle) error {\n")
+	fmt.Fprintf(&buf, "\treturn bundle.AddMessages(language.MustParse(%q),\n", lang)
+	for _, msg := range unique {
+		if msg.Description != "" {
+			fmt.Fprintf(&buf, "\t\t&i18n.Message{ID: %q, Description: %q, Other: %s},\n", msg.ID, msg.Description, quoteOther(msg.Other))
+			continue
+		}
+		fmt.Fprintf(&buf, "\t\t&i18n.Message{ID: %q, Other: %s},\n", msg.ID, quoteOther(msg.Other))
+	}
+	buf.WriteString("\t)\n}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+// writeRegisterFile 将注册消息的 Go 源文件写入 path
+func writeRegisterFile(path, pkg, lang string, msgs []Message) error {
+	data, err := generateRegisterFile(pkg, lang, msgs)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRegisterFile(t *testing.T) {
+	data, err := generateRegisterFile("messages", defaultSourceLanguage, []Message{
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "czcg", Other: "操作成功"},
+		{ID: "nhsj", Other: "你好世界"},
+		{ID: "dy", Other: `点击"确定"`},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `// Code generated by str2go-i18n. DO NOT EDIT.
+
+package messages
+
+import (
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+// RegisterMessages 将提取的消息注册到 bundle
+func RegisterMessages(bundle *i18n.Bundle) error {
+	return bundle.AddMessages(language.MustParse("zh-Hans"),
+		&i18n.Message{ID: "czcg", Other: "操作成功"},
+		&i18n.Message{ID: "dy", Other: `+"`点击\"确定\"`"+`},
+		&i18n.Message{ID: "nhsj", Other: "你好世界"},
+	)
+}
+`, string(data))
+
+	// 生成的文件是合法的 Go 代码且被识别为生成的文件，本工具不会再处理它
+	file, err := parser.ParseFile(token.NewFileSet(), "", data, parser.ParseComments)
+	assert.NoError(t, err)
+	assert.True(t, ast.IsGenerated(file))
+
+	// 原文作为说明时写入 Description
+	data, err = generateRegisterFile("messages", defaultSourceLanguage, []Message{{ID: "nhsj", Description: "你好世界"}})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `&i18n.Message{ID: "nhsj", Description: "你好世界", Other: ""},`)
+}
+
+func TestRegisterOut(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package app
+
+func example() string {
+	return "你好世界"
+}`)
+	output := filepath.Join(tempDir, "output.go")
+	register := filepath.Join(tempDir, "messages_gen.go")
+
+	assert.Equal(t, exitChanges, run([]string{"-register-out", register, input, output}))
+
+	data, err := os.ReadFile(register)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "package app")
+	assert.Contains(t, string(data), `&i18n.Message{ID: "nhsj", Other: "你好世界"}`)
+}
+
+// 再次运行时注册文件仍包含已替换的消息
+func TestRegisterOutRerun(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package app
+
+func example() (string, string) {
+	return "你好世界", "操作成功"
+}`)
+	register := filepath.Join(tempDir, "messages_gen.go")
+
+	assert.Equal(t, exitChanges, run([]string{"-w", "-limit", "1", "-register-out", register, input}))
+	assert.Equal(t, exitChanges, run([]string{"-w", "-register-out", register, input}))
+	data, err := os.ReadFile(register)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `&i18n.Message{ID: "nhsj", Other: "你好世界"}`)
+	assert.Contains(t, string(data), `&i18n.Message{ID: "czcg", Other: "操作成功"}`)
+
+	// 没有新的字符串时也保留全部消息
+	assert.Equal(t, exitOK, run([]string{"-w", "-register-out", register, input}))
+	again, err := os.ReadFile(register)
+	assert.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+)
+
+// migrationReport -report-md 写入的迁移报告，便于附在迁移的 PR 中
+type migrationReport struct {
+	// Files 处理的文件及其中替换的字符串数量，按路径排序
+	Files []reportFile
+	// Changed 改写的源码文件数量，只更新消息文件或 -dry-run 时为 0
+	Changed int
+	// Messages 提取的字符串数量，同一原文出现多次时分别计数
+	Messages int
+	// IDs 不同消息ID的数量
+	IDs int
+	// Collisions 通过后缀解决的ID冲突数量，按追加了后缀的原文计数
+	Collisions int
+	// Warnings 处理过程中的警告
+	Warnings []Diagnostic
+}
+
+// reportFile 迁移报告中的一个文件
+type reportFile struct {
+	Path    string
+	Strings int
+}
+
+// newMigrationReport 根据转换结果生成迁移报告，written 表示源码文件是否被改写
+func newMigrationReport(files []*ast.File, fset *token.FileSet, msgs []Message, collisions int, written bool, diags []Diagnostic) migrationReport {
+	counts := map[string]int{}
+	ids := map[string]bool{}
+	for _, msg := range msgs {
+		counts[msg.Position.Filename]++
+		ids[msg.ID] = true
+	}
+
+	r := migrationReport{Messages: len(msgs), IDs: len(ids), Collisions: collisions}
+	for _, file := range files {
+		path := fset.File(file.Pos()).Name()
+		r.Files = append(r.Files, reportFile{Path: path, Strings: counts[path]})
+		if written && counts[path] > 0 {
+			r.Changed++
+		}
+	}
+	sort.Slice(r.Files, func(i, j int) bool { return r.Files[i].Path < r.Files[j].Path })
+	for _, d := range diags {
+		if d.Severity == severityWarning {
+			r.Warnings = append(r.Warnings, d)
+		}
+	}
+	return r
+}
+
+// markdown 将报告编码为 Markdown，依次为概览、文件和警告三节
+func (r migrationReport) markdown() []byte {
+	var b strings.Builder
+	b.WriteString("# 国际化迁移报告\n\n")
+
+	b.WriteString("## 概览\n\n")
+	b.WriteString("| 项目 | 数量 |\n")
+	b.WriteString("| --- | ---: |\n")
+	fmt.Fprintf(&b, "| 处理的文件 | %d |\n", len(r.Files))
+	fmt.Fprintf(&b, "| 修改的文件 | %d |\n", r.Changed)
+	fmt.Fprintf(&b, "| 提取的字符串 | %d |\n", r.Messages)
+	fmt.Fprintf(&b, "| 消息ID | %d |\n", r.IDs)
+	fmt.Fprintf(&b, "| 解决的ID冲突 | %d |\n", r.Collisions)
+	fmt.Fprintf(&b, "| 警告 | %d |\n", len(r.Warnings))
+
+	b.WriteString("\n## 文件\n\n")
+	b.WriteString("| 文件 | 字符串 |\n")
+	b.WriteString("| --- | ---: |\n")
+	for _, f := range r.Files {
+		fmt.Fprintf(&b, "| `%s` | %d |\n", f.Path, f.Strings)
+	}
+
+	b.WriteString("\n## 警告\n\n")
+	if len(r.Warnings) == 0 {
+		b.WriteString("无\n")
+	}
+	for _, d := range r.Warnings {
+		pos := d.File
+		if d.Line > 0 {
+			pos = fmt.Sprintf("%s:%d", d.File, d.Line)
+		}
+		fmt.Fprintf(&b, "- `%s` %s: %s\n", pos, d.Code, d.Message)
+	}
+	return []byte(b.String())
+}
+
+// writeReport 将迁移报告写入 path，已存在时覆盖
+func writeReport(path string, r migrationReport) error {
+	return os.WriteFile(path, r.markdown(), 0644)
+}
+
+// countResolvedCollisions 计算文件中因ID冲突被追加了后缀的原文数量，需在转换前调用
+func countResolvedCollisions(files []*ast.File, fset *token.FileSet, opts Options) (int, error) {
+	var cands []candidate
+	for _, file := range files {
+		cands = append(cands, collectCandidates(file, fset, opts)...)
+	}
+	cands = append(cands, existingCandidates(files, fset, opts)...)
+	base, _, err := baseMessageIDs(cands, opts)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := planMessageIDs(cands, opts)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for other, id := range ids {
+		if id != base[other] {
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportMarkdown(t *testing.T) {
+	tempDir := t.TempDir()
+	a := writeTestFile(t, tempDir, "a.go", `package main
+
+var greeting = "你好世界"
+var again = "你好世界"
+`)
+	b := writeTestFile(t, tempDir, "b.go", `package main
+
+var notice = "你好时间"
+var page = "第3页"
+`)
+	clean := writeTestFile(t, tempDir, "clean.go", "package main\n")
+	reportPath := filepath.Join(tempDir, "i18n-report.md")
+
+	assert.Equal(t, exitChanges, run([]string{"-w", "-embedded-numbers", "warn", "-report-md", reportPath, a, b, clean}))
+	data, err := os.ReadFile(reportPath)
+	assert.NoError(t, err)
+	report := string(data)
+
+	// 依次包含概览、文件和警告三节
+	overview := strings.Index(report, "## 概览")
+	files := strings.Index(report, "## 文件")
+	warnings := strings.Index(report, "## 警告")
+	assert.True(t, overview >= 0 && overview < files && files < warnings)
+
+	assert.Contains(t, report, "| 处理的文件 | 3 |\n")
+	assert.Contains(t, report, "| 修改的文件 | 2 |\n")
+	assert.Contains(t, report, "| 提取的字符串 | 4 |\n")
+	assert.Contains(t, report, "| 消息ID | 3 |\n")
+	assert.Contains(t, report, "| 解决的ID冲突 | 1 |\n")
+	assert.Contains(t, report, "| 警告 | 1 |\n")
+	assert.Contains(t, report, "| `"+a+"` | 2 |\n")
+	assert.Contains(t, report, "| `"+b+"` | 2 |\n")
+	assert.Contains(t, report, "| `"+clean+"` | 0 |\n")
+	assert.Contains(t, report, "- `"+b+":4` "+codeEmbeddedNumber+": ")
+
+	// 只更新消息文件时源码不计入修改的文件，没有警告时写明无
+	c := writeTestFile(t, tempDir, "c.go", `package main
+
+var greeting = "你好世界"
+`)
+	assert.Equal(t, exitChanges, run([]string{"extract", "-bundle-out", filepath.Join(tempDir, "active.zh.toml"), "-report-md", reportPath, c}))
+	data, err = os.ReadFile(reportPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "| 修改的文件 | 0 |\n")
+	assert.Contains(t, string(data), "| 提取的字符串 | 1 |\n")
+	assert.Contains(t, string(data), "## 警告\n\n无\n")
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// reuseInFunctions 同一函数中多次出现的消息只调用一次：在第一次出现的语句前把调用结果赋给局部变量，
+// 所有出现的位置改为引用该变量。calls 为可以复用的调用及其消息ID
+// 变量声明在包含所有出现位置的最内层语句块中，可以是闭包的函数体或 switch、select 的分支；
+// Localizer 表达式引用了闭包自己声明的标识符（如闭包的 ctx 参数）时，闭包内外的出现不合并，以免引用外层的同名变量；
+// 含有 goto 的函数不处理，以免 goto 跳过变量声明
+func reuseInFunctions(file *ast.File, calls map[*ast.CallExpr]string) {
+	if len(calls) == 0 {
+		return
+	}
+	names := identNames(file)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil || hasGoto(fn.Body) {
+			continue
+		}
+		// 不同函数中的变量互不影响，可以同名
+		used := make(map[string]bool, len(names))
+		for name := range names {
+			used[name] = true
+		}
+		reuseInBody(fn.Body, calls, used)
+	}
+}
+
+// reuseInBody 在一个函数体中复用消息，used 为文件中已使用的标识符，新变量名会加入其中
+func reuseInBody(body *ast.BlockStmt, calls map[*ast.CallExpr]string, used map[string]bool) {
+	// 按出现顺序分组，Localizer 不同的调用不能共用；
+	// Localizer 引用了闭包声明的标识符时，同样写法的表达式在闭包内外指向不同的变量，按闭包区分
+	groups := map[string][]*ast.CallExpr{}
+	var keys []string
+	var lits []*ast.FuncLit
+	var stack []ast.Node
+	litIDs := map[*ast.FuncLit]int{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil {
+			if _, ok := stack[len(stack)-1].(*ast.FuncLit); ok {
+				lits = lits[:len(lits)-1]
+			}
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if call, ok := n.(*ast.CallExpr); ok {
+			if id, ok := calls[call]; ok {
+				key := id + "\x00" + types.ExprString(call.Fun)
+				if lit := declaringFuncLit(lits, call.Fun); lit != nil {
+					key += "\x00" + strconv.Itoa(litIDs[lit])
+				}
+				if groups[key] == nil {
+					keys = append(keys, key)
+				}
+				groups[key] = append(groups[key], call)
+				return false
+			}
+		}
+		if lit, ok := n.(*ast.FuncLit); ok {
+			litIDs[lit] = len(litIDs) + 1
+			lits = append(lits, lit)
+		}
+		stack = append(stack, n)
+		return true
+	})
+
+	names := map[*ast.CallExpr]string{}
+	decls := map[*[]ast.Stmt]map[int][]ast.Stmt{}
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 {
+			continue
+		}
+		first := group[0]
+		list := innermostStmtList(body, group)
+		index := stmtIndex(*list, first.Pos())
+		if index < 0 {
+			continue
+		}
+		name := localName(calls[first], used)
+		for _, call := range group {
+			names[call] = name
+		}
+		at := (*list)[index].Pos()
+		clearPositions(first)
+		if decls[list] == nil {
+			decls[list] = map[int][]ast.Stmt{}
+		}
+		decls[list][index] = append(decls[list][index], &ast.AssignStmt{
+			Lhs:    []ast.Expr{&ast.Ident{Name: name, NamePos: at}},
+			TokPos: at,
+			Tok:    token.DEFINE,
+			Rhs:    []ast.Expr{placeAt(first, at, at+1)},
+		})
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	// 先替换所有出现的位置，再插入声明，避免声明中的调用也被替换
+	// 引用变量的标识符不带位置，沿用原调用的位置会使打印时在参数后换行
+	astutil.Apply(body, func(cursor *astutil.Cursor) bool {
+		call, ok := cursor.Node().(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if name, ok := names[call]; ok {
+			cursor.Replace(ast.NewIdent(name))
+			return false
+		}
+		return true
+	}, nil)
+
+	for list, byIndex := range decls {
+		stmts := make([]ast.Stmt, 0, len(*list)+len(byIndex))
+		for i, stmt := range *list {
+			stmts = append(stmts, byIndex[i]...)
+			stmts = append(stmts, stmt)
+		}
+		*list = stmts
+	}
+}
+
+// declaringFuncLit 返回 lits 中声明了 expr 所引用标识符的最内层闭包，都没有声明时返回 nil
+// lits 为从外到内包含 expr 的闭包
+func declaringFuncLit(lits []*ast.FuncLit, expr ast.Expr) *ast.FuncLit {
+	refs := map[string]bool{}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			refs[ident.Name] = true
+		}
+		return true
+	})
+	for i := len(lits) - 1; i >= 0; i-- {
+		for name := range declaredNames(lits[i]) {
+			if refs[name] {
+				return lits[i]
+			}
+		}
+	}
+	return nil
+}
+
+// declaredNames 返回闭包的参数、返回值以及函数体中直接声明的标识符，不包括其中嵌套的闭包
+func declaredNames(lit *ast.FuncLit) map[string]bool {
+	names := map[string]bool{}
+	for _, list := range []*ast.FieldList{lit.Type.Params, lit.Type.Results} {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			for _, name := range field.Names {
+				names[name.Name] = true
+			}
+		}
+	}
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, lhs := range n.Lhs {
+					if ident, ok := lhs.(*ast.Ident); ok {
+						names[ident.Name] = true
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				for _, expr := range []ast.Expr{n.Key, n.Value} {
+					if ident, ok := expr.(*ast.Ident); ok {
+						names[ident.Name] = true
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for _, name := range n.Names {
+				names[name.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// innermostStmtList 返回 body 中包含 calls 全部位置的最内层语句列表，可以是语句块、闭包的函数体，
+// 或 switch、select 中一个分支的语句；switch、select 的 {} 中只能是分支，不能插入声明
+func innermostStmtList(body *ast.BlockStmt, calls []*ast.CallExpr) *[]ast.Stmt {
+	start, end := calls[0].Pos(), calls[0].End()
+	for _, call := range calls[1:] {
+		if call.Pos() < start {
+			start = call.Pos()
+		}
+		if call.End() > end {
+			end = call.End()
+		}
+	}
+	innermost := &body.List
+	clauses := map[*ast.BlockStmt]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if n == nil || n.Pos() > start || n.End() < end {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SwitchStmt:
+			clauses[n.Body] = true
+		case *ast.TypeSwitchStmt:
+			clauses[n.Body] = true
+		case *ast.SelectStmt:
+			clauses[n.Body] = true
+		case *ast.BlockStmt:
+			if !clauses[n] {
+				innermost = &n.List
+			}
+		case *ast.CaseClause:
+			innermost = &n.Body
+		case *ast.CommClause:
+			innermost = &n.Body
+		}
+		return true
+	})
+	return innermost
+}
+
+// stmtIndex 返回语句列表中包含 pos 的语句下标，不存在时返回 -1
+func stmtIndex(list []ast.Stmt, pos token.Pos) int {
+	for i, stmt := range list {
+		if stmt.Pos() <= pos && pos < stmt.End() {
+			return i
+		}
+	}
+	return -1
+}
+
+// localName 根据消息ID生成未被使用的局部变量名，如 nhsj 生成 msgNhsj，重名时追加数字
+func localName(id string, used map[string]bool) string {
+	runes := []rune(id)
+	for i, r := range runes {
+		if r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			runes[i] = '_'
+		}
+	}
+	base := "msg" + capitalize(string(runes))
+	name := base
+	for n := 2; used[name]; n++ {
+		name = base + strconv.Itoa(n)
+	}
+	used[name] = true
+	return name
+}
+
+// identNames 收集文件中出现的所有标识符
+func identNames(file *ast.File) map[string]bool {
+	names := map[string]bool{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			names[ident.Name] = true
+		}
+		return true
+	})
+	return names
+}
+
+// hasGoto 检查语句中是否含有 goto
+func hasGoto(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if branch, ok := n.(*ast.BranchStmt); ok && branch.Tok == token.GOTO {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// clearPositions 清除生成的调用中各节点的位置，以便用 placeAt 放到新的位置
+// 只用于全部由工具生成的调用，其中没有原代码的节点
+func clearPositions(call *ast.CallExpr) {
+	ast.Inspect(call, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			n.NamePos = token.NoPos
+		case *ast.BasicLit:
+			n.ValuePos = token.NoPos
+		case *ast.CallExpr:
+			n.Lparen, n.Rparen = token.NoPos, token.NoPos
+		case *ast.CompositeLit:
+			n.Lbrace, n.Rbrace = token.NoPos, token.NoPos
+		case *ast.UnaryExpr:
+			n.OpPos = token.NoPos
+		case *ast.KeyValueExpr:
+			n.Colon = token.NoPos
+		}
+		return true
+	})
+}
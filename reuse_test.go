@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReuseInFunc(t *testing.T) {
+	input := `package main
+
+func show(args ...any) {}
+
+func example(n int) string {
+	show("你好世界")
+	for i := 0; i < n; i++ {
+		show("你好世界", i)
+	}
+	f := func() string { return "你好世界" }
+	show("只出现一次")
+	return f()
+}
+
+func other() string {
+	return "你好世界"
+}
+`
+
+	output, msgs := transformString(t, input, Options{ReuseInFunc: true})
+	// 每次出现仍记录为一条消息
+	assert.Len(t, msgs, 5)
+
+	// 第一次出现的语句前赋给局部变量，三次出现都引用该变量
+	assert.Equal(t, 2, strings.Count(output, `Other: "你好世界"`))
+	assert.Contains(t, output, "func example(n int) string {\n\tmsgNhsj := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: \"nhsj\"")
+	assert.Contains(t, output, "\tshow(msgNhsj)\n")
+	assert.Contains(t, output, "\t\tshow(msgNhsj, i)\n")
+	assert.Contains(t, output, "f := func() string { return msgNhsj }")
+
+	// 只出现一次的字符串和其他函数中的字符串照常替换
+	assert.Contains(t, output, `show(i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "zcxyc"`)
+	assert.Contains(t, output, "func other() string {\n\treturn i18n.Localizer.MustLocalize(")
+	typeCheck(t, output)
+
+	// 默认不复用
+	output, _ = transformString(t, input, Options{})
+	assert.NotContains(t, output, "msgNhsj")
+}
+
+func TestReuseInFuncNames(t *testing.T) {
+	output, _ := transformString(t, `package main
+
+func show(string) {}
+
+func example() {
+	msgNh := 1
+	_ = msgNh
+	show("你好")
+	show("你好")
+}
+
+func jump() {
+	show("你好")
+	goto end
+end:
+	show("你好")
+}
+`, Options{ReuseInFunc: true})
+
+	// 变量名与已有的标识符重名时追加数字
+	assert.Contains(t, output, "\tmsgNh2 := i18n.Localizer.MustLocalize(")
+	assert.Equal(t, 2, strings.Count(output, "show(msgNh2)"))
+	// 含有 goto 的函数不处理
+	assert.Equal(t, 3, strings.Count(output, `Other: "你好"`))
+	typeCheck(t, output)
+
+	assert.Equal(t, "msgUser_name", localName("user.name", map[string]bool{}))
+}
+
+// 只在闭包中出现的消息在闭包的函数体中声明变量，可以引用闭包的 ctx 参数
+func TestReuseInClosure(t *testing.T) {
+	output, _ := transformString(t, `package main
+
+import "context"
+
+func use(string) {}
+
+func outer() func(context.Context) {
+	return func(ctx context.Context) {
+		use("你好世界")
+		if ctx != nil {
+			use("你好世界")
+		}
+	}
+}
+`, Options{ReuseInFunc: true, LocalizerFromCtx: true})
+
+	// ctx 只在闭包中可见，变量声明在闭包的函数体中；引用变量的位置照常打印在一行内
+	assert.Contains(t, output, `func outer() func(context.Context) {
+	return func(ctx context.Context) {
+		msgNhsj := i18n.GetLocalizer(ctx).MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj", DefaultMessage: &i18n.Message{ID: "nhsj", Other: "你好世界"}})
+		use(msgNhsj)
+		if ctx != nil {
+			use(msgNhsj)
+		}
+	}
+}`)
+}
+
+// 只在 switch、select 的一个分支中出现的消息在该分支的语句中声明变量
+func TestReuseInCaseClause(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name: "switch",
+			body: `switch x.(int) {
+	case 1:
+		use("你好世界")
+		use("你好世界")
+	}`,
+			expected: "\tcase 1:\n\t\tmsgNhsj := i18n.Localizer.MustLocalize(",
+		},
+		{
+			name: "type switch",
+			body: `switch x.(type) {
+	case int:
+		use("你好世界")
+		use("你好世界")
+	}`,
+			expected: "\tcase int:\n\t\tmsgNhsj := i18n.Localizer.MustLocalize(",
+		},
+		{
+			name: "select",
+			body: `select {
+	default:
+		use("你好世界")
+		use("你好世界")
+	}`,
+			expected: "\tdefault:\n\t\tmsgNhsj := i18n.Localizer.MustLocalize(",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, _ := transformString(t, "package main\n\nfunc use(string) {}\n\nfunc example(x any) {\n\t"+tt.body+"\n}\n", Options{ReuseInFunc: true})
+			assert.Contains(t, output, tt.expected)
+			assert.Equal(t, 2, strings.Count(output, "use(msgNhsj)"))
+			typeCheck(t, output)
+		})
+	}
+}
+
+// 闭包声明了同名的 ctx 时，闭包内外的 i18n.GetLocalizer(ctx) 不是同一个值，不能共用变量
+func TestReuseAcrossClosureCtx(t *testing.T) {
+	output, _ := transformString(t, `package main
+
+func use(string) {}
+
+func shadowed(ctx int) {
+	go func(ctx int) {
+		use("你好世界")
+	}(1)
+	use("你好世界")
+}
+
+func shared(ctx int) {
+	go func() {
+		use("你好世界")
+	}()
+	use("你好世界")
+}
+`, Options{ReuseInFunc: true, LocalizerFromCtx: true})
+
+	assert.Contains(t, output, `func shadowed(ctx int) {
+	go func(ctx int) {
+		use(i18n.GetLocalizer(ctx).MustLocalize(`)
+	// 闭包没有声明 ctx 时引用的是外层的参数，照常共用
+	assert.Contains(t, output, `func shared(ctx int) {
+	msgNhsj := i18n.GetLocalizer(ctx).MustLocalize(`)
+	assert.Equal(t, 2, strings.Count(output, "use(msgNhsj)"))
+}
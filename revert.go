@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// revert 将 transform 生成的 go-i18n 调用还原为原始字符串，返回被还原的消息ID（已去重并排序）
+// 原文从 opts.MessageField 指定的字段读取，-source-as-description 生成的调用从 Description 读取；还原后文件中不再使用 go-i18n 时删除其导入
+func revert(file *ast.File, fset *token.FileSet, opts Options) []string {
+	seen := map[string]bool{}
+	var ids []string
+	qualifier := i18nQualifier(file)
+
+	pre := func(cursor *astutil.Cursor) bool {
+		call, ok := cursor.Node().(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+
+		id, other, ok := parseLocalizeCall(call, qualifier, opts)
+		if !ok {
+			return true
+		}
+		// -no-panic 生成的 Localize 调用需要同时去掉接收 error 的 _
+		if call.Fun.(*ast.SelectorExpr).Sel.Name == "Localize" && !dropErrorResult(cursor) {
+			return true
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+		cursor.Replace(&ast.BasicLit{Kind: token.STRING, Value: other.Value})
+		return false
+	}
+
+	astutil.Apply(file, pre, nil)
+
+	// 全部还原后不再使用的 go-i18n 导入一并删除
+	if len(ids) > 0 && !usesI18nImport(file, qualifier) {
+		// 重命名导入需要按导入的名称删除，空白导入不是转换添加的，保留
+		var names []string
+		for _, imp := range file.Imports {
+			if imp.Path.Value == `"`+i18nImportPath+`"` && imp.Name != nil && imp.Name.Name != "_" {
+				names = append(names, imp.Name.Name)
+			}
+		}
+		astutil.DeleteImport(fset, file, i18nImportPath)
+		for _, name := range names {
+			astutil.DeleteNamedImport(fset, file, name, i18nImportPath)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// usesI18nImport 检查文件中是否还通过 qualifier 引用 go-i18n
+// 与 astutil.UsesImport 不同，只有空白导入时仍检查 i18nQualifier 返回的名称；点导入时无法判断，视为仍在使用
+func usesI18nImport(file *ast.File, qualifier string) bool {
+	if qualifier == "" {
+		return true
+	}
+	used := false
+	ast.Inspect(file, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == qualifier && ident.Obj == nil {
+				used = true
+			}
+		}
+		return !used
+	})
+	return used
+}
+
+// parseLocalizeCall 解析 transform 生成的调用
+// i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: ..., DefaultMessage: &i18n.Message{..., <field>: ...}})
+// 方法也可以是 -no-panic 生成的 Localize；Localizer 也可以是 -localizer-from-ctx 生成的 i18n.GetLocalizer(ctx)；包名为 i18nQualifier 返回的 qualifier，
+// 重命名导入、点导入和 goi18n 别名生成的调用同样识别
+// 返回消息ID和保存原文的字面量，调用形式不匹配时返回 false
+// 原文通常在 opts.MessageField 指定的字段中；该字段为空或等于 opts.OtherPlaceholder 且有 Description 时，
+// 是 -source-as-description 生成的调用，原文在 Description 中
+func parseLocalizeCall(call *ast.CallExpr, qualifier string, opts Options) (string, *ast.BasicLit, bool) {
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || (fun.Sel.Name != "MustLocalize" && fun.Sel.Name != "Localize") || !(isI18nType(fun.X, qualifier, "Localizer") || isCtxLocalizer(fun.X, qualifier)) {
+		return "", nil, false
+	}
+	if len(call.Args) != 1 {
+		return "", nil, false
+	}
+
+	config := i18nCompositeLit(call.Args[0], qualifier, "LocalizeConfig")
+	if config == nil {
+		return "", nil, false
+	}
+
+	var id string
+	var other, description *ast.BasicLit
+	for _, elt := range config.Elts {
+		key, value, ok := keyValue(elt)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "MessageID":
+			if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				id = unquoteLit(lit)
+			}
+		case "TemplateData":
+			// 模板消息无法无损还原为原来的 fmt.Sprintf 调用，保持不变
+			return "", nil, false
+		case "DefaultMessage":
+			msg := i18nCompositeLit(value, qualifier, "Message")
+			if msg == nil {
+				continue
+			}
+			for _, melt := range msg.Elts {
+				mkey, mvalue, ok := keyValue(melt)
+				lit, isLit := mvalue.(*ast.BasicLit)
+				if !ok || !isLit || lit.Kind != token.STRING {
+					continue
+				}
+				switch mkey {
+				case opts.messageField():
+					other = lit
+				case "Description":
+					description = lit
+				}
+			}
+		}
+	}
+
+	if id == "" || other == nil {
+		return "", nil, false
+	}
+	if description != nil && (unquoteLit(other) == "" || unquoteLit(other) == opts.OtherPlaceholder) {
+		return id, description, true
+	}
+	return id, other, true
+}
+
+// i18nCompositeLit 返回形如 &i18n.<typeName>{...} 的复合字面量，包名为 i18nQualifier 返回的 qualifier
+func i18nCompositeLit(expr ast.Expr, qualifier, typeName string) *ast.CompositeLit {
+	unary, ok := expr.(*ast.UnaryExpr)
+	if !ok || unary.Op != token.AND {
+		return nil
+	}
+	lit, ok := unary.X.(*ast.CompositeLit)
+	if !ok || !isI18nType(lit.Type, qualifier, typeName) {
+		return nil
+	}
+	return lit
+}
+
+// isSelector 检查表达式是否为 x.sel 形式的选择器
+func isSelector(expr ast.Expr, x, sel string) bool {
+	s, ok := expr.(*ast.SelectorExpr)
+	if !ok || s.Sel.Name != sel {
+		return false
+	}
+	ident, ok := s.X.(*ast.Ident)
+	return ok && ident.Name == x
+}
+
+// keyValue 返回 Key: Value 形式元素的键名和值
+func keyValue(expr ast.Expr) (string, ast.Expr, bool) {
+	kv, ok := expr.(*ast.KeyValueExpr)
+	if !ok {
+		return "", nil, false
+	}
+	key, ok := kv.Key.(*ast.Ident)
+	if !ok {
+		return "", nil, false
+	}
+	return key.Name, kv.Value, true
+}
+
+// printRevertedIDs 输出被还原的消息ID，便于从消息文件中清理
+func printRevertedIDs(ids []string) {
+	if len(ids) == 0 {
+		fmt.Println("未找到可还原的 go-i18n 调用")
+		return
+	}
+	fmt.Println("已还原以下消息ID:")
+	for i, id := range ids {
+		fmt.Printf("%d. %s\n", i+1, id)
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevert(t *testing.T) {
+	input := `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+func example() {
+	a := "你好世界"
+	b := "操作成功"
+	c := "你好世界"
+	d := "Hello"
+}`
+
+	transformed, _ := transformString(t, input, Options{})
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", transformed, parser.ParseComments)
+	assert.NoError(t, err)
+
+	ids := revert(file, fset, Options{})
+	assert.Equal(t, []string{"czcg", "nhsj"}, ids)
+
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	output := buf.String()
+	assert.NotContains(t, output, "MustLocalize")
+	assert.Contains(t, output, `a := "你好世界"`)
+	assert.Contains(t, output, `b := "操作成功"`)
+	assert.Contains(t, output, `d := "Hello"`)
+}
+
+func TestRevertAliasedImport(t *testing.T) {
+	input := `package main
+
+import (
+	"context"
+
+	"example.com/app/i18n"
+)
+
+func example(ctx context.Context) {
+	i18n.Tr("发送成功")
+}
+
+func plain() string {
+	return "操作成功"
+}`
+
+	// 已有同名的 i18n 包时生成的调用使用 goi18n，同样可以还原，原有的 i18n 调用保持不变
+	transformed, _ := transformString(t, input, Options{LocalizerFromCtx: true})
+	assert.Contains(t, transformed, "goi18n.GetLocalizer(ctx).MustLocalize(&goi18n.LocalizeConfig{")
+	assert.Contains(t, transformed, "goi18n.Localizer.MustLocalize(&goi18n.LocalizeConfig{")
+
+	fset, file := parseSource(t, transformed)
+	assert.Equal(t, []string{"czcg", "fscg"}, revert(file, fset, Options{}))
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.NotContains(t, buf.String(), "goi18n")
+	assert.Contains(t, buf.String(), `i18n.Tr("发送成功")`)
+	assert.Contains(t, buf.String(), `return "操作成功"`)
+}
+
+func TestRevertRenamedImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   string
+		prefix string
+	}{
+		{"renamed", `loc "` + i18nImportPath + `"`, "loc."},
+		{"dot", `. "` + i18nImportPath + `"`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := `package main
+
+import ` + tt.spec + `
+
+var _ = ` + tt.prefix + `Localizer
+
+func example() string {
+	return "你好世界"
+}`
+
+			transformed, _ := transformString(t, input, Options{})
+			assert.Contains(t, transformed, "return "+tt.prefix+"Localizer.MustLocalize(&"+tt.prefix+"LocalizeConfig{")
+
+			// 生成的调用可以被收集和还原，导入仍被使用时保留
+			fset, file := parseSource(t, transformed)
+			collected := collectWrappedMessages([]*ast.File{file}, fset, Options{})
+			assert.Equal(t, []Message{{ID: "nhsj", Other: "你好世界"}}, stripPositions(collected))
+			assert.Equal(t, []string{"nhsj"}, revert(file, fset, Options{}))
+			var buf strings.Builder
+			assert.NoError(t, printer.Fprint(&buf, fset, file))
+			assert.Contains(t, buf.String(), `return "你好世界"`)
+			assert.Contains(t, buf.String(), i18nImportPath)
+		})
+	}
+}
+
+func TestRevertMessageField(t *testing.T) {
+	input := `package main
+
+func example() string {
+	return "你好世界"
+}`
+
+	// 按 -message-field 指定的字段还原
+	opts := Options{MessageField: "Text"}
+	transformed, _ := transformString(t, input, opts)
+	assert.Contains(t, transformed, `Text: "你好世界"`)
+	fset, file := parseSource(t, transformed)
+	assert.Empty(t, revert(file, fset, Options{}))
+	assert.Equal(t, []string{"nhsj"}, revert(file, fset, opts))
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.Contains(t, buf.String(), `return "你好世界"`)
+
+	// 命令行中同样生效
+	path := writeTestFile(t, t.TempDir(), "input.go", transformed)
+	assert.Equal(t, exitChanges, run([]string{"-revert", "-message-field", "Text", "-w", path}))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "MustLocalize")
+}
+
+func TestRevertSourceAsDescription(t *testing.T) {
+	input := `package main
+
+func example() (string, string) {
+	return "你好世界", "操作成功"
+}
+`
+
+	// 原文在 Description 中，Other 为空或为占位文本，还原后与转换前相同
+	for _, opts := range []Options{{SourceAsDescription: true}, {SourceAsDescription: true, OtherPlaceholder: "TODO"}} {
+		transformed, _ := transformString(t, input, opts)
+		assert.Contains(t, transformed, `Description: "你好世界"`)
+
+		fset, file := parseSource(t, transformed)
+		assert.Equal(t, []string{"czcg", "nhsj"}, revert(file, fset, opts))
+		var buf strings.Builder
+		assert.NoError(t, printFile(&buf, fset, file, opts))
+		assert.Equal(t, input, buf.String())
+	}
+}
+
+func TestRevertNoPanic(t *testing.T) {
+	input := `package main
+
+var title = "标题"
+
+func example() string {
+	var name string = "姓名"
+	msg := "成功"
+	msg = "失败"
+	println("参数")
+	_ = name
+	return msg
+}
+`
+
+	// -no-panic 生成的 Localize 调用连同接收 error 的 _ 一起还原，与转换前相同
+	opts := Options{NoPanic: true}
+	transformed, _ := transformString(t, input, opts)
+	assert.Contains(t, transformed, `msg, _ := i18n.Localizer.Localize(`)
+
+	fset, file := parseSource(t, transformed)
+	assert.Equal(t, []string{"bt", "cg", "cs", "sb", "xm"}, revert(file, fset, opts))
+	var buf strings.Builder
+	assert.NoError(t, printFile(&buf, fset, file, opts))
+	assert.Equal(t, input, buf.String())
+
+	// 左侧不是 _ 时不是生成的调用，保持不变
+	other := `package main
+
+func example() {
+	msg, err := i18n.Localizer.Localize(&i18n.LocalizeConfig{MessageID: "cg", DefaultMessage: &i18n.Message{ID: "cg", Other: "成功"}})
+}`
+	fset, file = parseSource(t, other)
+	assert.Empty(t, revert(file, fset, Options{}))
+}
+
+func TestRevertIgnoresOtherCalls(t *testing.T) {
+	input := `package main
+
+func example() {
+	s := loc.MustLocalize(&i18n.LocalizeConfig{MessageID: "nhsj"})
+}`
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", input, parser.ParseComments)
+	assert.NoError(t, err)
+
+	assert.Empty(t, revert(file, fset, Options{}))
+}
+
+func TestRevertRemovesUnusedImport(t *testing.T) {
+	input := `package main
+
+import (
+	"fmt"
+
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func example() {
+	fmt.Println("你好世界")
+}`
+
+	transformed, _ := transformString(t, input, Options{})
+	assert.Contains(t, transformed, i18nImportPath)
+
+	fset, file := parseSource(t, transformed)
+	revert(file, fset, Options{})
+
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.NotContains(t, buf.String(), i18nImportPath)
+	assert.Contains(t, buf.String(), `"fmt"`)
+}
+
+func TestRevertKeepsUsedImport(t *testing.T) {
+	input := `package main
+
+import "github.com/nicksnyder/go-i18n/v2/i18n"
+
+var bundle *i18n.Bundle
+
+func example() {
+	s := "你好世界"
+}`
+
+	transformed, _ := transformString(t, input, Options{})
+	fset, file := parseSource(t, transformed)
+	revert(file, fset, Options{})
+
+	var buf strings.Builder
+	assert.NoError(t, printer.Fprint(&buf, fset, file))
+	assert.Contains(t, buf.String(), i18nImportPath)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// argsAnnotation 匹配 //i18n:args=user,time 形式的注释，用于指定模板字段名
+var argsAnnotation = regexp.MustCompile(`^//\s*i18n:args=(\S*)`)
+
+// templateField 模板字段名需要是合法的标识符
+var templateField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sprintfMessage 一个可以转换为 go-i18n 模板消息的 fmt.Sprintf 调用
+type sprintfMessage struct {
+	// Format 格式字符串，由 + 连接的常量已合并
+	Format *ast.BasicLit
+	// Template 格式字符串中的占位符替换为 {{.字段名}} 后的文本
+	Template string
+	// Keys 模板字段名，与 Args 一一对应
+	Keys []string
+	// Args 格式化参数
+	Args []ast.Expr
+	// ArgsErr 注释中指定的字段名不合法时的错误，此时使用默认字段名
+	ArgsErr error
+}
+
+// splitFormat 按占位符拆分格式字符串，返回占位符之间的文本，%% 还原为 %
+// 只支持不带宽度、精度等修饰的 %s、%d、%v，这些占位符与模板输出的结果一致；
+// 包含其他占位符或 {{ 时无法转换，ok 为 false
+func splitFormat(format string) (texts []string, ok bool) {
+	if strings.Contains(format, "{{") {
+		return nil, false
+	}
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i+1 >= len(format) {
+			return nil, false
+		}
+		i++
+		switch format[i] {
+		case '%':
+			b.WriteByte('%')
+		case 's', 'd', 'v':
+			texts = append(texts, b.String())
+			b.Reset()
+		default:
+			return nil, false
+		}
+	}
+	return append(texts, b.String()), true
+}
+
+// parseSprintf 检查调用是否为可以转换的 fmt.Sprintf：格式字符串为包含中文的常量，
+// 占位符都受支持且数量与参数一致。names 为注释中指定的字段名，为空时使用 Arg0、Arg1…
+func parseSprintf(call *ast.CallExpr, names string) (sprintfMessage, bool) {
+	if calleeName(call) != "fmt.Sprintf" || len(call.Args) == 0 || call.Ellipsis.IsValid() {
+		return sprintfMessage{}, false
+	}
+	format, _ := stringLiteral(call.Args[0])
+	if format == nil || !containsChinese(unquoteLit(format)) {
+		return sprintfMessage{}, false
+	}
+	texts, ok := splitFormat(unquoteLit(format))
+	args := call.Args[1:]
+	if !ok || len(texts)-1 != len(args) {
+		return sprintfMessage{}, false
+	}
+
+	msg := sprintfMessage{Format: format, Args: args}
+	if names != "" {
+		msg.Keys, msg.ArgsErr = parseArgNames(names, len(args))
+	}
+	if msg.Keys == nil {
+		for i := range args {
+			msg.Keys = append(msg.Keys, fmt.Sprintf("Arg%d", i))
+		}
+	}
+
+	var b strings.Builder
+	for i, text := range texts {
+		b.WriteString(text)
+		if i < len(msg.Keys) {
+			b.WriteString("{{." + msg.Keys[i] + "}}")
+		}
+	}
+	msg.Template = b.String()
+	return msg, true
+}
+
+// parseArgNames 解析注释中逗号分隔的字段名，数量需与参数一致且不能重复
+func parseArgNames(names string, count int) ([]string, error) {
+	keys := splitList(names)
+	if len(keys) != count {
+		return nil, fmt.Errorf("字段名 %q 的数量与参数数量 %d 不一致", names, count)
+	}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !templateField.MatchString(key) {
+			return nil, fmt.Errorf("字段名 %q 不合法，需以字母或 _ 开头且只包含字母、数字、_", key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("字段名 %q 重复", key)
+		}
+		seen[key] = true
+	}
+	return keys, nil
+}
+
+// walkSprintfs 遍历文件中可以转换为模板消息的 fmt.Sprintf 调用，对每个调用调用 visit
+// 按后序遍历，参数中嵌套的调用先于外层调用处理，visit 可以通过 cursor 替换当前调用
+// 与普通字符串一样按 wrapAllowed 筛选，检测规则和 -embedded-numbers 作用于格式字符串
+func walkSprintfs(file *ast.File, fset *token.FileSet, opts Options, visit func(cursor *astutil.Cursor, msg sprintfMessage)) {
+	if !opts.ConvertSprintf {
+		return
+	}
+	names := collectLineAnnotations(file, fset, argsAnnotation)
+	detector := opts.detector()
+
+	astutil.Apply(file, nil, func(cursor *astutil.Cursor) bool {
+		call, ok := cursor.Node().(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		parsed, ok := parseSprintf(call, "")
+		if !ok || !wrapAllowed(cursor, parsed.Format, detector, opts) {
+			return true
+		}
+		n, _ := names.take(fset.Position(call.Pos()).Line)
+		msg, _ := parseSprintf(call, n)
+		visit(cursor, msg)
+		return true
+	})
+}
+
+// isSprintfFormat 检查当前节点是否为可以转换的 fmt.Sprintf 调用的格式字符串
+// 这些字符串整体转换为模板消息，不再单独替换
+func isSprintfFormat(cursor *astutil.Cursor) bool {
+	call, ok := cursor.Parent().(*ast.CallExpr)
+	if !ok || cursor.Name() != "Args" || cursor.Index() != 0 {
+		return false
+	}
+	_, ok = parseSprintf(call, "")
+	return ok
+}
+
+// newTemplateLocalizeCall 生成带有 TemplateData 的 go-i18n 调用
+func newTemplateLocalizeCall(msgID, description string, msg sprintfMessage, field string) *ast.CallExpr {
+	call := newLocalizeCall(msgID, msg.Template, description, field)
+
+	data := &ast.CompositeLit{
+		Type: &ast.MapType{
+			Key:   ast.NewIdent("string"),
+			Value: ast.NewIdent("any"),
+		},
+	}
+	for i, key := range msg.Keys {
+		data.Elts = append(data.Elts, &ast.KeyValueExpr{
+			Key:   &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(key)},
+			Value: msg.Args[i],
+		})
+	}
+
+	config := call.Args[0].(*ast.UnaryExpr).X.(*ast.CompositeLit)
+	config.Elts = append(config.Elts, &ast.KeyValueExpr{
+		Key:   ast.NewIdent("TemplateData"),
+		Value: data,
+	})
+	return call
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected []string
+		ok       bool
+	}{
+		{format: "欢迎 %s", expected: []string{"欢迎 ", ""}, ok: true},
+		{format: "%s 共有 %d 个，完成 100%%", expected: []string{"", " 共有 ", " 个，完成 100%"}, ok: true},
+		{format: "没有占位符", expected: []string{"没有占位符"}, ok: true},
+		{format: "金额 %.2f", ok: false},
+		{format: "名称 %q", ok: false},
+		{format: "第 %[1]d 行", ok: false},
+		{format: "末尾 %", ok: false},
+		{format: "模板 {{.Name}} %s", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			texts, ok := splitFormat(tt.format)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestConvertSprintf(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(name string, n int) string {
+	return fmt.Sprintf("欢迎 %s，你有 %d 条新消息", name, n)
+}`
+
+	output, msgs := transformString(t, input, Options{ConvertSprintf: true})
+	assert.Equal(t, []Message{{ID: "hynyt", Other: "欢迎 {{.Arg0}}，你有 {{.Arg1}} 条新消息"}}, msgs)
+	assert.Contains(t, output, `TemplateData: map[string]any{"Arg0": name, "Arg1": n}`)
+	// fmt 不再被使用，导入被移除
+	assert.NotContains(t, output, `"fmt"`)
+	assert.Contains(t, output, i18nImportPath)
+
+	_, err := parser.ParseFile(token.NewFileSet(), "", output, 0)
+	assert.NoError(t, err)
+}
+
+func TestConvertSprintfArgNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		data     string
+	}{
+		{
+			name: "names on previous line",
+			input: `package main
+
+import "fmt"
+
+func example(u string, t string) string {
+	//i18n:args=user,time
+	return fmt.Sprintf("%s 于 %s 登录", u, t)
+}`,
+			expected: "{{.user}} 于 {{.time}} 登录",
+			data:     `{"user": u, "time": t}`,
+		},
+		{
+			name: "names on same line",
+			input: `package main
+
+import "fmt"
+
+func example(u string) string {
+	return fmt.Sprintf("欢迎 %s", u) //i18n:args=User
+}`,
+			expected: "欢迎 {{.User}}",
+			data:     `{"User": u}`,
+		},
+		{
+			name: "wrong count falls back to defaults",
+			input: `package main
+
+import "fmt"
+
+func example(u string, t string) string {
+	//i18n:args=user
+	return fmt.Sprintf("%s 于 %s 登录", u, t)
+}`,
+			expected: "{{.Arg0}} 于 {{.Arg1}} 登录",
+			data:     `{"Arg0": u, "Arg1": t}`,
+		},
+		{
+			name: "invalid name falls back to defaults",
+			input: `package main
+
+import "fmt"
+
+func example(u string) string {
+	//i18n:args=1user
+	return fmt.Sprintf("欢迎 %s", u)
+}`,
+			expected: "欢迎 {{.Arg0}}",
+			data:     `{"Arg0": u}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, msgs := transformString(t, tt.input, Options{ConvertSprintf: true})
+			if assert.Len(t, msgs, 1) {
+				assert.Equal(t, tt.expected, msgs[0].Other)
+			}
+			assert.Contains(t, output, tt.data)
+		})
+	}
+}
+
+func TestConvertSprintfArgsAnnotationError(t *testing.T) {
+	fset, file := parseSource(t, `package main
+
+import "fmt"
+
+func example(u string) string {
+	//i18n:args=a,a
+	return fmt.Sprintf("欢迎 %s 和 %s", u, u)
+}`)
+
+	cands := collectCandidates(file, fset, Options{ConvertSprintf: true})
+	if assert.Len(t, cands, 1) {
+		assert.ErrorContains(t, cands[0].ArgsErr, "重复")
+	}
+}
+
+func TestConvertSprintfFallback(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(price float64, name string) string {
+	s := fmt.Sprintf("金额 %.2f", price)
+	return fmt.Sprintf("用户 %s", name)
+}`
+
+	output, msgs := transformString(t, input, Options{ConvertSprintf: true})
+	// 不支持的占位符按原来的方式只替换格式字符串，fmt 仍被使用
+	assert.Equal(t, []Message{
+		{ID: "je", Other: "金额 %.2f"},
+		{ID: "yh", Other: "用户 {{.Arg0}}"},
+	}, msgs)
+	assert.Contains(t, output, `s := fmt.Sprintf(i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `"fmt"`)
+}
+
+func TestPrintfArguments(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(name string) string {
+	fmt.Printf("%s\n", "中文参数")
+	fmt.Printf("用户 %s: %s\n", name, "中文参数")
+	return fmt.Sprintf("欢迎 %s", "访客")
+}`
+
+	// 参数位置的中文单独替换，不含中文的格式字符串保持不变，含中文的格式字符串连同占位符一起替换
+	output, msgs := transformString(t, input, Options{})
+	assert.Equal(t, []Message{
+		{ID: "zwcs", Other: "中文参数"},
+		{ID: "yh", Other: "用户 %s: %s\n"},
+		{ID: "zwcs", Other: "中文参数"},
+		{ID: "hy", Other: "欢迎 %s"},
+		{ID: "fk", Other: "访客"},
+	}, msgs)
+	assert.Contains(t, output, `fmt.Printf("%s\n", i18n.Localizer.MustLocalize(`)
+	assert.Contains(t, output, `Other: "用户 %s: %s\n"}}), name, i18n.Localizer.MustLocalize(`)
+	pkgs := map[string]string{"fmt": `package fmt
+
+func Printf(format string, a ...any) (int, error) { return 0, nil }
+
+func Sprintf(format string, a ...any) string { return format }
+`}
+	typeCheckWith(t, output, pkgs)
+
+	// 转换为模板消息时，参数位置的中文替换后作为 TemplateData 的值
+	output, msgs = transformString(t, input, Options{ConvertSprintf: true})
+	assert.Equal(t, []Message{
+		{ID: "zwcs", Other: "中文参数"},
+		{ID: "yh", Other: "用户 %s: %s\n"},
+		{ID: "zwcs", Other: "中文参数"},
+		{ID: "hy", Other: "欢迎 {{.Arg0}}"},
+		{ID: "fk", Other: "访客"},
+	}, msgs)
+	assert.Contains(t, output, `TemplateData: map[string]any{"Arg0": i18n.Localizer.MustLocalize(`)
+	typeCheckWith(t, output, pkgs)
+}
+
+func TestConvertSprintfNested(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(name string, n int) string {
+	return fmt.Sprintf("提示: %s", fmt.Sprintf("%s 有 %d 个任务", name, n))
+}`
+
+	fset, file := parseSource(t, input)
+	opts := Options{ConvertSprintf: true}
+	collected, err := collectMessages(file, fset, opts)
+	assert.NoError(t, err)
+
+	output, msgs := transformString(t, input, opts)
+	assert.Equal(t, stripPositions(collected), msgs)
+	assert.Equal(t, []Message{
+		{ID: "ts", Other: "提示: {{.Arg0}}"},
+		{ID: "ygrw", Other: "{{.Arg0}} 有 {{.Arg1}} 个任务"},
+	}, msgs)
+	assert.NotContains(t, output, "fmt.Sprintf")
+}
+
+// 转换 fmt.Sprintf 时与普通字符串使用相同的筛选条件
+func TestConvertSprintfFilters(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func show(string) {}
+
+func errorf(code int, msg string) {}
+
+func example(name string, n int) {
+	s := fmt.Sprintf("你好 %s", name)
+	show(fmt.Sprintf("欢迎 %s", s))
+	errorf(0, fmt.Sprintf("用户 %s 不存在", name))
+	errorf(len(fmt.Sprintf("第1页，共 %d 页", n)), "失败")
+}`
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected []Message
+	}{
+		{
+			name: "wrap-args",
+			opts: Options{ConvertSprintf: true, WrapArgs: map[string]int{"errorf": 0}},
+			expected: []Message{
+				{ID: "nh", Other: "你好 {{.Arg0}}"},
+				{ID: "hy", Other: "欢迎 {{.Arg0}}"},
+				{ID: "dygy", Other: "第1页，共 {{.Arg0}} 页"},
+			},
+		},
+		{
+			name: "wrap-parents",
+			opts: Options{ConvertSprintf: true, WrapParents: []string{"CallExpr"}},
+			expected: []Message{
+				{ID: "hy", Other: "欢迎 {{.Arg0}}"},
+				{ID: "yhbcz", Other: "用户 {{.Arg0}} 不存在"},
+				{ID: "dygy", Other: "第1页，共 {{.Arg0}} 页"},
+				{ID: "sb", Other: "失败"},
+			},
+		},
+		{
+			name: "embedded-numbers",
+			opts: Options{ConvertSprintf: true, EmbeddedNumbers: embeddedNumbersSkip},
+			expected: []Message{
+				{ID: "nh", Other: "你好 {{.Arg0}}"},
+				{ID: "hy", Other: "欢迎 {{.Arg0}}"},
+				{ID: "yhbcz", Other: "用户 {{.Arg0}} 不存在"},
+				{ID: "sb", Other: "失败"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, msgs := transformString(t, input, tt.opts)
+			assert.ElementsMatch(t, tt.expected, msgs)
+		})
+	}
+}
+
+func TestRevertKeepsTemplateMessages(t *testing.T) {
+	transformed, _ := transformString(t, `package main
+
+import "fmt"
+
+func example(name string) string {
+	return fmt.Sprintf("欢迎 %s", name)
+}`, Options{ConvertSprintf: true})
+
+	fset, file := parseSource(t, transformed)
+	assert.Empty(t, revert(file, fset, Options{}))
+}
+
+// 转换的 fmt.Sprintf 与普通字符串按源码位置排序，-limit 和 -unique-suffix=counter 都按出现顺序处理
+func TestConvertSprintfSourceOrder(t *testing.T) {
+	input := `package main
+
+import "fmt"
+
+func example(n int) {
+	println("第一个")
+	println(fmt.Sprintf("共%d条", n))
+}`
+
+	output, msgs := transformString(t, input, Options{ConvertSprintf: true, Limit: 1})
+	assert.Equal(t, []Message{{ID: "dyg", Other: "第一个"}}, msgs)
+	assert.Contains(t, output, `println(fmt.Sprintf("共%d条", n))`)
+
+	// 再次运行时替换剩余的 fmt.Sprintf
+	_, msgs = transformString(t, output, Options{ConvertSprintf: true, Limit: 1})
+	assert.Equal(t, []Message{{ID: "gt", Other: "共{{.Arg0}}条"}}, msgs)
+
+	_, msgs = transformString(t, `package main
+
+import "fmt"
+
+func example(name string) {
+	println("你好")
+	println(fmt.Sprintf("你好%s", name))
+}`, Options{ConvertSprintf: true, UniqueSuffix: uniqueSuffixCounter})
+	assert.Equal(t, []Message{
+		{ID: "nh", Other: "你好"},
+		{ID: "nh_1", Other: "你好{{.Arg0}}"},
+	}, msgs)
+}
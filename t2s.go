@@ -0,0 +1,51 @@
+package main
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// tsCharacters 繁体到简体的单字转换表，来自 OpenCC 项目（https://github.com/BYVoid/OpenCC）的
+// data/dictionary/TSCharacters.txt，以 Apache License 2.0 发布，许可证和来源说明见 data/LICENSE.OpenCC、data/NOTICE
+// 每行为 繁体字\t简体字[ 其他候选]，有多个候选时取第一个
+//
+//go:embed data/TSCharacters.txt
+var tsCharacters string
+
+var (
+	t2sOnce  sync.Once
+	t2sTable map[rune]rune
+)
+
+// loadT2STable 解析繁简转换表
+func loadT2STable() {
+	t2sTable = make(map[rune]rune)
+	for _, line := range strings.Split(tsCharacters, "\n") {
+		trad, simp, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		if i := strings.IndexByte(simp, ' '); i >= 0 {
+			simp = simp[:i]
+		}
+		t, tn := utf8.DecodeRuneInString(trad)
+		s, sn := utf8.DecodeRuneInString(simp)
+		if tn != len(trad) || sn != len(simp) {
+			continue
+		}
+		t2sTable[t] = s
+	}
+}
+
+// toSimplified 将字符串中的繁体字转换为简体字，其余字符保持不变
+func toSimplified(s string) string {
+	t2sOnce.Do(loadT2STable)
+	return strings.Map(func(r rune) rune {
+		if simp, ok := t2sTable[r]; ok {
+			return simp
+		}
+		return r
+	}, s)
+}
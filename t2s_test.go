@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToSimplified(t *testing.T) {
+	assert.Equal(t, "显示设置", toSimplified("顯示設置"))
+	assert.Equal(t, "显示 OK", toSimplified("显示 OK"))
+}
+
+func TestTraditional2SimplifiedSharesID(t *testing.T) {
+	input := `package main
+
+func example() {
+	a := "顯示"
+	b := "显示"
+}`
+
+	output, msgs := transformString(t, input, Options{Traditional2Simplified: true})
+	assert.Equal(t, []Message{
+		{ID: "xs", Other: "顯示"},
+		{ID: "xs", Other: "显示"},
+	}, msgs)
+	assert.Contains(t, output, `Other: "顯示"`)
+	assert.Contains(t, output, `Other: "显示"`)
+
+	// 不转换时繁简写法视为不同文本，需要追加后缀区分
+	_, msgs = transformString(t, input, Options{})
+	assert.NotEqual(t, msgs[0].ID, msgs[1].ID)
+}
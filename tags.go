@@ -0,0 +1,120 @@
+package main
+
+import (
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// tagPair 结构体标签中的一个 key:"value" 键值对
+type tagPair struct {
+	Key   string
+	Value string
+	// start、end 为带引号的值在标签字符串中的范围，用于只替换值而保留其余内容
+	start, end int
+}
+
+// parseStructTag 按 reflect.StructTag 的规则解析标签内容（不含外层引号）
+// 遇到格式不正确的部分时停止解析，返回已解析的键值对
+func parseStructTag(tag string) []tagPair {
+	var pairs []tagPair
+	offset := 0
+	for tag != "" {
+		// 跳过前导空格
+		i := 0
+		for i < len(tag) && tag[i] == ' ' {
+			i++
+		}
+		tag = tag[i:]
+		offset += i
+		if tag == "" {
+			break
+		}
+
+		// 键为非控制字符序列，不包含空格、引号和冒号
+		i = 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			break
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+		offset += i + 1
+
+		// 扫描带引号的值
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			break
+		}
+		quoted := tag[:i+1]
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			break
+		}
+		pairs = append(pairs, tagPair{Key: key, Value: value, start: offset, end: offset + i + 1})
+		tag = tag[i+1:]
+		offset += i + 1
+	}
+	return pairs
+}
+
+// rewriteTagMessages 遍历结构体标签中 keys 指定键的中文值，用 rewrite 的返回值替换原值
+// rewrite 返回原值时标签保持不变；标签的其余部分（包括空白）原样保留
+func rewriteTagMessages(file *ast.File, keys []string, rewrite func(lit *ast.BasicLit, value string) string) {
+	if len(keys) == 0 {
+		return
+	}
+	wanted := map[string]bool{}
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		field, ok := n.(*ast.Field)
+		if !ok || field.Tag == nil {
+			return true
+		}
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return true
+		}
+
+		var b strings.Builder
+		last := 0
+		changed := false
+		for _, pair := range parseStructTag(tag) {
+			if !wanted[pair.Key] || !hasChinese.MatchString(pair.Value) {
+				continue
+			}
+			value := rewrite(field.Tag, pair.Value)
+			if value == pair.Value {
+				continue
+			}
+			b.WriteString(tag[last:pair.start])
+			b.WriteString(strconv.Quote(value))
+			last = pair.end
+			changed = true
+		}
+		if changed {
+			b.WriteString(tag[last:])
+			field.Tag.Value = quoteTag(b.String())
+		}
+		return true
+	})
+}
+
+// quoteTag 将标签内容重新编码为字面量，优先使用反引号
+func quoteTag(tag string) string {
+	if strconv.CanBackquote(tag) {
+		return "`" + tag + "`"
+	}
+	return strconv.Quote(tag)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStructTag(t *testing.T) {
+	tag := `json:"name"  label:"用户名" placeholder:"请输入\"用户名\""`
+	pairs := parseStructTag(tag)
+
+	var keys, values []string
+	for _, pair := range pairs {
+		keys = append(keys, pair.Key)
+		values = append(values, pair.Value)
+		assert.Equal(t, `"`, tag[pair.start:pair.start+1])
+		assert.Equal(t, `"`, tag[pair.end-1:pair.end])
+	}
+	assert.Equal(t, []string{"json", "label", "placeholder"}, keys)
+	assert.Equal(t, []string{"name", "用户名", `请输入"用户名"`}, values)
+
+	// 格式不正确时只返回已解析的部分
+	assert.Len(t, parseStructTag(`json:"name" broken`), 1)
+}
+
+func TestTagKeys(t *testing.T) {
+	input := "package main\n\ntype Form struct {\n" +
+		"\tName string `json:\"name\"  label:\"用户名\" placeholder:\"请输入用户名\" validate:\"required\" desc:\"描述\"`\n" +
+		"}\n"
+
+	output, msgs := transformString(t, input, Options{TagKeys: []string{"label", "placeholder"}})
+	assert.Equal(t, []Message{
+		{ID: "yhm", Other: "用户名"},
+		{ID: "qsryh", Other: "请输入用户名"},
+	}, msgs)
+	// 只提取时标签保持不变
+	assert.Contains(t, output, "`json:\"name\"  label:\"用户名\" placeholder:\"请输入用户名\" validate:\"required\" desc:\"描述\"`")
+
+	output, msgs = transformString(t, input, Options{TagKeys: []string{"label", "placeholder"}, TagIDs: true})
+	assert.Len(t, msgs, 2)
+	assert.Contains(t, output, "`json:\"name\"  label:\"yhm\" placeholder:\"qsryh\" validate:\"required\" desc:\"描述\"`")
+	assert.NotContains(t, output, "MustLocalize")
+}
+
+// 只提取时标签保持原文，不算作需要替换；-tag-ids 替换后的值不再是原文，再次检查时也没有需要替换的字符串
+func TestTagKeysCheckAfterFix(t *testing.T) {
+	tests := []struct {
+		flags  []string
+		before int
+	}{
+		{[]string{"-tag-keys", "label"}, exitOK},
+		{[]string{"-tag-keys", "label", "-tag-ids"}, exitChanges},
+	}
+	for _, tt := range tests {
+		path := writeTestFile(t, t.TempDir(), "form.go", "package main\n\ntype Form struct {\n\tName string `label:\"用户名\"`\n}\n")
+		assert.Equal(t, tt.before, run(append(append([]string{"check"}, tt.flags...), path)))
+		assert.Equal(t, tt.before, run(append(append([]string{"fix"}, tt.flags...), path)))
+		assert.Equal(t, exitOK, run(append(append([]string{"check"}, tt.flags...), path)))
+		assert.Equal(t, exitOK, run(append(append([]string{"-l"}, tt.flags...), path)))
+	}
+}
+
+// 标签中的值替换为ID后，-prune 不会从消息文件中删除该ID，也不会修改其原文
+func TestTagIDsPruneAfterFix(t *testing.T) {
+	tempDir := t.TempDir()
+	path := writeTestFile(t, tempDir, "form.go", "package main\n\ntype Form struct {\n\tName string `label:\"用户名\"`\n}\n")
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+
+	assert.Equal(t, exitChanges, run([]string{"fix", "-tag-keys", "label", "-tag-ids", "-bundle-out", bundlePath, path}))
+	assert.Equal(t, exitOK, run([]string{"fix", "-tag-keys", "label", "-tag-ids", "-prune", "-bundle-out", bundlePath, path}))
+	b, err := loadBundle(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle{"yhm": {"other": "用户名"}}, b)
+
+	// 只有ID的消息不会被写入新的消息文件
+	assert.Empty(t, mergeBundle(bundle{}, []Message{{ID: "yhm"}}, true).Added)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+)
+
+// bundleMismatch 代码与消息文件中的消息ID不一致的情况
+type bundleMismatch struct {
+	// Missing 代码中引用但消息文件中没有的ID
+	Missing []string
+	// Unreferenced 消息文件中有但代码中没有引用的ID
+	Unreferenced []string
+}
+
+// empty 代码与消息文件是否一致
+func (m bundleMismatch) empty() bool {
+	return len(m.Missing) == 0 && len(m.Unreferenced) == 0
+}
+
+// referencedMessageIDs 返回代码中 go-i18n 调用的 MessageID，以及从结构体标签中提取的消息ID
+// 标签中的消息没有对应的调用，按 msgs 中记录的位置识别
+func referencedMessageIDs(files []*ast.File, fset *token.FileSet, msgs []Message) map[string]bool {
+	ids := map[string]bool{}
+	tags := map[token.Position]bool{}
+	for _, file := range files {
+		qualifier := i18nQualifier(file)
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				if n.Tag != nil {
+					tags[fset.Position(n.Tag.Pos())] = true
+				}
+			case *ast.UnaryExpr:
+				config := i18nCompositeLit(n, qualifier, "LocalizeConfig")
+				if config == nil {
+					return true
+				}
+				for _, elt := range config.Elts {
+					key, value, ok := keyValue(elt)
+					if !ok || key != "MessageID" {
+						continue
+					}
+					if lit, ok := value.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+						ids[unquoteLit(lit)] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	for _, msg := range msgs {
+		if tags[msg.Position] {
+			ids[msg.ID] = true
+		}
+	}
+	return ids
+}
+
+// verifyBundle 检查转换后的代码引用的消息ID与 path 指向的消息文件是否一一对应
+// 消息文件应只包含这些文件中的消息，与 -prune 的假设相同
+func verifyBundle(path string, files []*ast.File, fset *token.FileSet, msgs []Message) (bundleMismatch, error) {
+	b, err := loadBundle(path)
+	if err != nil {
+		return bundleMismatch{}, err
+	}
+	referenced := referencedMessageIDs(files, fset, msgs)
+
+	var m bundleMismatch
+	for id := range referenced {
+		if _, ok := b[id]; !ok {
+			m.Missing = append(m.Missing, id)
+		}
+	}
+	for id := range b {
+		if !referenced[id] {
+			m.Unreferenced = append(m.Unreferenced, id)
+		}
+	}
+	sort.Strings(m.Missing)
+	sort.Strings(m.Unreferenced)
+	return m, nil
+}
+
+// printBundleMismatch 输出代码与消息文件不一致的消息ID
+func printBundleMismatch(w io.Writer, path string, m bundleMismatch) {
+	for _, id := range m.Missing {
+		fmt.Fprintf(w, "错误: 代码中引用的消息ID %s 不在消息文件 %s 中\n", id, path)
+	}
+	for _, id := range m.Unreferenced {
+		fmt.Fprintf(w, "错误: 消息文件 %s 中的消息ID %s 没有被代码引用\n", path, id)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyBundle(t *testing.T) {
+	src := `package main
+
+type Form struct {
+	Name string ` + "`label:\"姓名\"`" + `
+}
+
+func example() {
+	a := "你好世界"
+	b := i18n.Localizer.MustLocalize(&i18n.LocalizeConfig{MessageID: "old", DefaultMessage: &i18n.Message{ID: "old", Other: "旧的消息"}})
+}`
+	opts := Options{TagKeys: []string{"label"}}
+	fset, file := parseSource(t, src)
+	msgs, err := transform(file, fset, opts)
+	assert.NoError(t, err)
+
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "active.zh.toml")
+	_, err = writeBundle(path, msgs, opts)
+	assert.NoError(t, err)
+
+	// 已替换的调用引用的ID不在新生成的消息文件中
+	mismatch, err := verifyBundle(path, []*ast.File{file}, fset, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, bundleMismatch{Missing: []string{"old"}}, mismatch)
+
+	var buf bytes.Buffer
+	printBundleMismatch(&buf, path, mismatch)
+	assert.Contains(t, buf.String(), "代码中引用的消息ID old 不在消息文件")
+
+	// 补上后一致，标签中的消息不需要对应的调用
+	_, err = writeBundle(path, append(msgs, Message{ID: "old", Other: "旧的消息"}), opts)
+	assert.NoError(t, err)
+	mismatch, err = verifyBundle(path, []*ast.File{file}, fset, msgs)
+	assert.NoError(t, err)
+	assert.True(t, mismatch.empty())
+
+	// 消息文件中多出的ID
+	_, err = writeBundle(path, []Message{{ID: "unused", Other: "多余的消息"}}, opts)
+	assert.NoError(t, err)
+	mismatch, err = verifyBundle(path, []*ast.File{file}, fset, msgs)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"unused"}, mismatch.Unreferenced)
+}
+
+func TestVerifyBundleFlag(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeTestFile(t, tempDir, "input.go", `package main
+
+var greeting = "你好世界"
+`)
+	bundlePath := filepath.Join(tempDir, "active.zh.toml")
+	writeTestFile(t, tempDir, "active.zh.toml", "jqsy = \"旧的消息\"\n")
+
+	// 消息文件中保留了代码不再引用的ID
+	assert.Equal(t, 1, run([]string{"extract", "-verify-bundle", "-bundle-out", bundlePath, input}))
+	assert.Equal(t, exitChanges, run([]string{"extract", "-verify-bundle", "-prune", "-bundle-out", bundlePath, input}))
+
+	assert.Equal(t, exitUsage, run([]string{"-w", "-verify-bundle", input}))
+}